@@ -2,32 +2,108 @@
 package checkgrp
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"os"
+	"sync"
+	"time"
 
 	"go.uber.org/zap"
 )
 
+// Check reports an error when the part of the node it covers isn't ready
+// to serve traffic.
+type Check func(ctx context.Context) error
+
 // Handlers manages the set of check endpoints.
 type Handlers struct {
-	Build string
-	Log   *zap.SugaredLogger
+	Build  string
+	Log    *zap.SugaredLogger
+	Checks map[string]Check
+}
+
+// checkTimeout is the time every check gets to complete.
+const checkTimeout = 2 * time.Second
+
+// checkResult represents the outcome of a single check.
+type checkResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
 }
 
-// Readiness checks if the database is ready and if not will return a 500 status.
-// Do not respond by just returning an error because further up in the call
-// stack it will interpret that as a non-trusted error.
+// Readiness runs the registered checks and returns a 503 status with the
+// result of every check if any of them fails. A check that doesn't complete
+// within the timeout is reported as failed. Do not respond by just
+// returning an error because further up in the call stack it will interpret
+// that as a non-trusted error.
 func (h Handlers) Readiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+	defer cancel()
+
+	// Run the checks concurrently so a slow check doesn't delay the others.
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]checkResult, len(h.Checks))
+
+	for name, check := range h.Checks {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+
+			result := checkResult{Status: "ok"}
+			if err := check(ctx); err != nil {
+				result = checkResult{Status: "failed", Error: err.Error()}
+			}
+
+			mu.Lock()
+			results[name] = result
+			mu.Unlock()
+		}(name, check)
+	}
+
+	// Don't wait past the timeout for a check that ignores the context.
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	// Take a copy of the results since unfinished checks can still write to
+	// the map after this point.
+	mu.Lock()
+	checks := make(map[string]checkResult, len(h.Checks))
+	for name := range h.Checks {
+		result, exists := results[name]
+		if !exists {
+			result = checkResult{Status: "failed", Error: fmt.Sprintf("timed out: %s", ctx.Err())}
+		}
+		checks[name] = result
+	}
+	mu.Unlock()
+
 	status := "ok"
 	statusCode := http.StatusOK
-
-	// TODO: NOT SURE WHAT TO DO RIGHT NOW
+	for name, result := range checks {
+		if result.Error != "" {
+			status = "not ready"
+			statusCode = http.StatusServiceUnavailable
+			h.Log.Infow("readiness", "check", name, "ERROR", result.Error)
+		}
+	}
 
 	data := struct {
-		Status string `json:"status"`
+		Status string                 `json:"status"`
+		Checks map[string]checkResult `json:"checks"`
 	}{
 		Status: status,
+		Checks: checks,
 	}
 
 	if err := response(w, statusCode, data); err != nil {
@@ -0,0 +1,27 @@
+package checkgrp
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// StorageWritable checks a file can be created in the node's storage
+// directory.
+func StorageWritable(dir string) Check {
+	return func(ctx context.Context) error {
+		f, err := os.CreateTemp(dir, ".readiness-*")
+		if err != nil {
+			return fmt.Errorf("storage not writable: %w", err)
+		}
+
+		name := f.Name()
+		f.Close()
+
+		if err := os.Remove(name); err != nil {
+			return fmt.Errorf("removing readiness file: %w", err)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,107 @@
+// Package loggrp maintains the group of handlers for changing log levels
+// while the node is running.
+package loggrp
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ardanlabs/blockchain/foundation/logger"
+	"go.uber.org/zap"
+)
+
+// Handlers manages the set of log level endpoints.
+type Handlers struct {
+	Log    *zap.SugaredLogger
+	Logger *logger.Logger
+}
+
+// Level handles reading the level of every logger with GET and changing
+// the level of a logger with PUT.
+func (h Handlers) Level(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.list(w, r)
+	case http.MethodPut:
+		h.update(w, r)
+	default:
+		h.fail(w, r, http.StatusMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed))
+	}
+}
+
+// list returns the level of every logger.
+func (h Handlers) list(w http.ResponseWriter, r *http.Request) {
+	statusCode := http.StatusOK
+	if err := response(w, statusCode, h.Logger.Levels()); err != nil {
+		h.Log.Errorw("loglevel", "ERROR", err)
+	}
+
+	h.Log.Infow("loglevel", "statusCode", statusCode, "method", r.Method, "path", r.URL.Path, "remoteaddr", r.RemoteAddr)
+}
+
+// update changes the level of the logger named in the request. The root
+// logger is changed when no logger is named.
+func (h Handlers) update(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Logger string `json:"logger"`
+		Level  string `json:"level"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.fail(w, r, http.StatusBadRequest, "unable to decode payload")
+		return
+	}
+
+	if req.Logger == "" {
+		req.Logger = logger.RootName
+	}
+
+	if err := h.Logger.SetLevel(req.Logger, req.Level); err != nil {
+		h.fail(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	statusCode := http.StatusOK
+	if err := response(w, statusCode, h.Logger.Levels()); err != nil {
+		h.Log.Errorw("loglevel", "ERROR", err)
+	}
+
+	h.Log.Infow("loglevel", "statusCode", statusCode, "method", r.Method, "path", r.URL.Path, "remoteaddr", r.RemoteAddr, "subsystem", req.Logger, "level", req.Level)
+}
+
+// fail responds with the error message and logs the failed request.
+func (h Handlers) fail(w http.ResponseWriter, r *http.Request, statusCode int, msg string) {
+	data := struct {
+		Error string `json:"error"`
+	}{
+		Error: msg,
+	}
+
+	if err := response(w, statusCode, data); err != nil {
+		h.Log.Errorw("loglevel", "ERROR", err)
+	}
+
+	h.Log.Infow("loglevel", "statusCode", statusCode, "method", r.Method, "path", r.URL.Path, "remoteaddr", r.RemoteAddr, "ERROR", msg)
+}
+
+func response(w http.ResponseWriter, statusCode int, data any) error {
+
+	// Convert the response value to JSON.
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	// Set the content type and headers once we know marshaling has succeeded.
+	w.Header().Set("Content-Type", "application/json")
+
+	// Write the status code to the response.
+	w.WriteHeader(statusCode)
+
+	// Send the result back to the client.
+	if _, err := w.Write(jsonData); err != nil {
+		return err
+	}
+
+	return nil
+}
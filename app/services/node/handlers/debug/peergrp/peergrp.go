@@ -0,0 +1,102 @@
+// Package peergrp maintains the group of handlers for managing peer bans.
+package peergrp
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ardanlabs/blockchain/foundation/blockchain/peer"
+	"go.uber.org/zap"
+)
+
+// Handlers manages the set of peer endpoints.
+type Handlers struct {
+	Log    *zap.SugaredLogger
+	Scores *peer.Scores
+}
+
+// List returns the score and ban information for every known peer.
+func (h Handlers) List(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.fail(w, r, http.StatusMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed))
+		return
+	}
+
+	statusCode := http.StatusOK
+	if err := response(w, statusCode, h.Scores.List()); err != nil {
+		h.Log.Errorw("peers", "ERROR", err)
+	}
+
+	h.Log.Infow("peers", "statusCode", statusCode, "method", r.Method, "path", r.URL.Path, "remoteaddr", r.RemoteAddr)
+}
+
+// Unban removes the ban for the peer specified by the host query parameter.
+func (h Handlers) Unban(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.fail(w, r, http.StatusMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed))
+		return
+	}
+
+	host := r.URL.Query().Get("host")
+	if host == "" {
+		h.fail(w, r, http.StatusBadRequest, "host query parameter is required")
+		return
+	}
+
+	if err := h.Scores.Unban(host); err != nil {
+		h.fail(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data := struct {
+		Status string `json:"status"`
+		Host   string `json:"host"`
+	}{
+		Status: "unbanned",
+		Host:   host,
+	}
+
+	statusCode := http.StatusOK
+	if err := response(w, statusCode, data); err != nil {
+		h.Log.Errorw("unban", "ERROR", err)
+	}
+
+	h.Log.Infow("unban", "statusCode", statusCode, "method", r.Method, "path", r.URL.Path, "remoteaddr", r.RemoteAddr, "host", host)
+}
+
+// fail responds with the error message and logs the failed request.
+func (h Handlers) fail(w http.ResponseWriter, r *http.Request, statusCode int, msg string) {
+	data := struct {
+		Error string `json:"error"`
+	}{
+		Error: msg,
+	}
+
+	if err := response(w, statusCode, data); err != nil {
+		h.Log.Errorw("peers", "ERROR", err)
+	}
+
+	h.Log.Infow("peers", "statusCode", statusCode, "method", r.Method, "path", r.URL.Path, "remoteaddr", r.RemoteAddr, "ERROR", msg)
+}
+
+func response(w http.ResponseWriter, statusCode int, data any) error {
+
+	// Convert the response value to JSON.
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	// Set the content type and headers once we know marshaling has succeeded.
+	w.Header().Set("Content-Type", "application/json")
+
+	// Write the status code to the response.
+	w.WriteHeader(statusCode)
+
+	// Send the result back to the client.
+	if _, err := w.Write(jsonData); err != nil {
+		return err
+	}
+
+	return nil
+}
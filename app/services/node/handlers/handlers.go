@@ -7,10 +7,22 @@ import (
 	"net/http"
 	"net/http/pprof"
 	"os"
+	"time"
 
 	"github.com/ardanlabs/blockchain/app/services/node/handlers/debug/checkgrp"
+	"github.com/ardanlabs/blockchain/app/services/node/handlers/debug/loggrp"
+	"github.com/ardanlabs/blockchain/app/services/node/handlers/debug/peergrp"
+	"github.com/ardanlabs/blockchain/app/services/node/handlers/rpc"
 	v1 "github.com/ardanlabs/blockchain/app/services/node/handlers/v1"
+	"github.com/ardanlabs/blockchain/business/sys/validate"
+	"github.com/ardanlabs/blockchain/business/web/metrics"
 	"github.com/ardanlabs/blockchain/business/web/v1/mid"
+	"github.com/ardanlabs/blockchain/foundation/blockchain/database"
+	"github.com/ardanlabs/blockchain/foundation/blockchain/genesis"
+	"github.com/ardanlabs/blockchain/foundation/blockchain/peer"
+	"github.com/ardanlabs/blockchain/foundation/events"
+	"github.com/ardanlabs/blockchain/foundation/logger"
+	"github.com/ardanlabs/blockchain/foundation/tracer"
 	"github.com/ardanlabs/blockchain/foundation/web"
 	"go.uber.org/zap"
 )
@@ -19,57 +31,113 @@ import (
 type MuxConfig struct {
 	Shutdown chan os.Signal
 	Log      *zap.SugaredLogger
+	Tracer   *tracer.Tracer
+	Genesis  genesis.Genesis
+	DB       *database.Database
+	Peers    *peer.Scores
+	Evts     *events.Events
+
+	// MaxBodySize limits the size of request bodies in bytes.
+	MaxBodySize int64
+
+	// RateLimit configures the limit applied to each client on the
+	// public routes.
+	RateLimit mid.RateLimitConfig
+
+	// Cors configures Cross-Origin Resource Sharing for the mux. It is
+	// disabled when no origins are allowed.
+	Cors mid.CorsConfig
+
+	// AllowedNodes and AuthMaxSkew configure the authentication of
+	// node to node requests on the private mux.
+	AllowedNodes []string
+	AuthMaxSkew  time.Duration
 }
 
-// PublicMux constructs a http.Handler with all application routes defined.
-func PublicMux(cfg MuxConfig) http.Handler {
+// PublicMux constructs a web.App with all application routes defined.
+func PublicMux(cfg MuxConfig) *web.App {
 
 	// Construct the web.App which holds all routes as well as common Middleware.
 	app := web.NewApp(
 		cfg.Shutdown,
+		cfg.Log,
+		cfg.Tracer,
 		mid.Logger(cfg.Log),
 		mid.Errors(cfg.Log),
 		mid.Metrics(),
-		mid.Cors("*"),
+		mid.Cors(cfg.Cors),
 		mid.Panics(),
 	)
 
+	// Limit request bodies and validate the values handlers decode.
+	app.SetMaxBodySize(cfg.MaxBodySize)
+	app.SetValidator(validate.Check)
+
 	// Accept CORS 'OPTIONS' preflight requests if config has been provided.
-	// Don't forget to apply the CORS middleware to the routes that need it.
-	// Example Config: `conf:"default:https://MY_DOMAIN.COM"`
-	h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-		return nil
+	// The CORS middleware applied to the app sets the headers.
+	if cfg.Cors.Enabled() {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			return nil
+		}
+		app.Handle(http.MethodOptions, "", "/*", h)
 	}
-	app.Handle(http.MethodOptions, "", "/*", h, mid.Cors("*"))
 
 	// Load the v1 routes.
 	v1.PublicRoutes(app, v1.Config{
-		Log: cfg.Log,
+		Log:       cfg.Log,
+		Evts:      cfg.Evts,
+		RateLimit: cfg.RateLimit,
+	})
+
+	// Load the Ethereum JSON-RPC route.
+	rpc.Routes(app, rpc.Config{
+		Log:       cfg.Log,
+		Genesis:   cfg.Genesis,
+		DB:        cfg.DB,
+		RateLimit: cfg.RateLimit,
 	})
 
 	return app
 }
 
-// PrivateMux constructs a http.Handler with all application routes defined.
-func PrivateMux(cfg MuxConfig) http.Handler {
+// PrivateMux constructs a web.App with all application routes defined.
+func PrivateMux(cfg MuxConfig) *web.App {
+
+	// Browsers calling the private API need to send the node signature and
+	// the trace context along with the configured headers.
+	if cfg.Cors.Enabled() {
+		headers := make([]string, 0, len(cfg.Cors.AllowedHeaders)+3)
+		headers = append(headers, cfg.Cors.AllowedHeaders...)
+		headers = append(headers, peer.HeaderSignature, peer.HeaderTimestamp, tracer.HeaderTraceParent)
+		cfg.Cors.AllowedHeaders = headers
+	}
 
 	// Construct the web.App which holds all routes as well as common Middleware.
 	app := web.NewApp(
 		cfg.Shutdown,
+		cfg.Log,
+		cfg.Tracer,
 		mid.Logger(cfg.Log),
 		mid.Errors(cfg.Log),
 		mid.Metrics(),
-		mid.Cors("*"),
+		mid.Cors(cfg.Cors),
+		mid.PeerScore(cfg.Log, cfg.Peers),
+		mid.NodeAuth(cfg.AllowedNodes, cfg.AuthMaxSkew, cfg.Cors.Enabled()),
 		mid.Panics(),
 	)
 
+	// Limit request bodies and validate the values handlers decode.
+	app.SetMaxBodySize(cfg.MaxBodySize)
+	app.SetValidator(validate.Check)
+
 	// Accept CORS 'OPTIONS' preflight requests if config has been provided.
-	// Don't forget to apply the CORS middleware to the routes that need it.
-	// Example Config: `conf:"default:https://MY_DOMAIN.COM"`
-	h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-		return nil
+	// The CORS middleware applied to the app sets the headers.
+	if cfg.Cors.Enabled() {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			return nil
+		}
+		app.Handle(http.MethodOptions, "", "/*", h)
 	}
-	app.Handle(http.MethodOptions, "", "/*", h, mid.Cors("*"))
 
 	// Load the v1 routes.
 	v1.PrivateRoutes(app, v1.Config{
@@ -79,6 +147,18 @@ func PrivateMux(cfg MuxConfig) http.Handler {
 	return app
 }
 
+// DebugMuxConfig contains all the mandatory systems required by the debug handlers.
+type DebugMuxConfig struct {
+	Build  string
+	Log    *zap.SugaredLogger
+	Logger *logger.Logger
+	Peers  *peer.Scores
+
+	// Checks are run by the readiness endpoint. The node is only ready
+	// when every check passes.
+	Checks map[string]checkgrp.Check
+}
+
 // DebugStandardLibraryMux registers all the debug routes from the standard library
 // into a new mux bypassing the use of the DefaultServerMux. Using the
 // DefaultServerMux would be a security risk since a dependency could inject a
@@ -101,16 +181,35 @@ func DebugStandardLibraryMux() *http.ServeMux {
 // debug application routes for the service. This bypassing the use of the
 // DefaultServerMux. Using the DefaultServerMux would be a security risk since
 // a dependency could inject a handler into our service without us knowing it.
-func DebugMux(build string, log *zap.SugaredLogger) http.Handler {
+func DebugMux(cfg DebugMuxConfig) http.Handler {
 	mux := DebugStandardLibraryMux()
 
 	// Register debug check endpoints.
 	cgh := checkgrp.Handlers{
-		Build: build,
-		Log:   log,
+		Build:  cfg.Build,
+		Log:    cfg.Log,
+		Checks: cfg.Checks,
 	}
 	mux.HandleFunc("/debug/readiness", cgh.Readiness)
 	mux.HandleFunc("/debug/liveness", cgh.Liveness)
 
+	// Register peer management endpoints.
+	pgh := peergrp.Handlers{
+		Log:    cfg.Log,
+		Scores: cfg.Peers,
+	}
+	mux.HandleFunc("/debug/peers", pgh.List)
+	mux.HandleFunc("/debug/peers/unban", pgh.Unban)
+
+	// Register the endpoint for changing log levels at runtime.
+	lgh := loggrp.Handlers{
+		Log:    cfg.Log,
+		Logger: cfg.Logger,
+	}
+	mux.HandleFunc("/debug/loglevel", lgh.Level)
+
+	// Register the metrics endpoint in the Prometheus text format.
+	mux.Handle("/metrics", metrics.Handler())
+
 	return mux
 }
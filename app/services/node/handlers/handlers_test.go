@@ -0,0 +1,67 @@
+package handlers_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ardanlabs/blockchain/app/services/node/handlers"
+	"github.com/ardanlabs/blockchain/business/web/v1/mid"
+	"github.com/ardanlabs/blockchain/foundation/tracer"
+	"github.com/ardanlabs/blockchain/foundation/web"
+	"go.uber.org/zap"
+)
+
+// Success and failure markers.
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+func TestOpenAPI(t *testing.T) {
+	type table struct {
+		name string
+		mux  func(handlers.MuxConfig) *web.App
+		cors bool
+	}
+
+	tt := []table{
+		{name: "public", mux: handlers.PublicMux},
+		{name: "public with cors", mux: handlers.PublicMux, cors: true},
+		{name: "private", mux: handlers.PrivateMux},
+		{name: "private with cors", mux: handlers.PrivateMux, cors: true},
+	}
+
+	t.Log("Given the need to document every route of the node's muxes.")
+	{
+		for testID, tst := range tt {
+			f := func(t *testing.T) {
+				t.Logf("\tTest %d:\tWhen building the %s mux.", testID, tst.name)
+				{
+					// The muxes are only constructed to read their routes, so
+					// none of the systems the handlers need are required.
+					cfg := handlers.MuxConfig{
+						Shutdown: make(chan os.Signal, 1),
+						Log:      zap.NewNop().Sugar(),
+						Tracer:   tracer.New("TEST", nil, nil),
+					}
+					if tst.cors {
+						cfg.Cors = mid.CorsConfig{AllowedOrigins: []string{"*"}}
+					}
+
+					doc, err := tst.mux(cfg).OpenAPI("Node API", "v1")
+					if err != nil {
+						t.Fatalf("\t%s\tTest %d:\tShould be able to build the OpenAPI document : %s.", failed, testID, err)
+					}
+					t.Logf("\t%s\tTest %d:\tShould be able to build the OpenAPI document.", success, testID)
+
+					if len(doc.Paths) == 0 {
+						t.Fatalf("\t%s\tTest %d:\tShould document at least one path.", failed, testID)
+					}
+					t.Logf("\t%s\tTest %d:\tShould document at least one path.", success, testID)
+				}
+			}
+
+			t.Run(tst.name, f)
+		}
+	}
+}
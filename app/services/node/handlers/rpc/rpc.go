@@ -0,0 +1,379 @@
+// Package rpc maintains the group of handlers for Ethereum JSON-RPC access.
+// Only the subset of methods that map onto this node's data is supported.
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/ardanlabs/blockchain/business/web/v1/mid"
+	"github.com/ardanlabs/blockchain/foundation/blockchain/database"
+	"github.com/ardanlabs/blockchain/foundation/blockchain/genesis"
+	"github.com/ardanlabs/blockchain/foundation/web"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"go.uber.org/zap"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Log       *zap.SugaredLogger
+	Genesis   genesis.Genesis
+	DB        *database.Database
+	RateLimit mid.RateLimitConfig
+}
+
+// Routes binds the JSON-RPC route.
+func Routes(app *web.App, cfg Config) {
+	rpc := Handlers{
+		Log:     cfg.Log,
+		Genesis: cfg.Genesis,
+		DB:      cfg.DB,
+	}
+
+	app.Handle(http.MethodPost, "", "/rpc", rpc.RPC, mid.RateLimit(cfg.RateLimit)).Document(web.Doc{
+		Summary:     "Ethereum JSON-RPC",
+		Description: "Executes a JSON-RPC 2.0 request. An array of requests is executed as a batch and answered with an array of responses.",
+		Request:     request{},
+		Response:    response{},
+	})
+}
+
+// =============================================================================
+
+// Set of JSON-RPC 2.0 error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+// request represents a JSON-RPC request. A request without an id is a
+// notification and doesn't get a response.
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+// response represents a JSON-RPC response. Only one of result or error is
+// set, but a result of null still has to be present.
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError represents a JSON-RPC error object.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface.
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// null is the id used when the request id can't be determined.
+var null = json.RawMessage("null")
+
+// =============================================================================
+
+// Handlers manages the set of JSON-RPC endpoints.
+type Handlers struct {
+	Log     *zap.SugaredLogger
+	Genesis genesis.Genesis
+	DB      *database.Database
+}
+
+// RPC handles a single or a batch of JSON-RPC requests.
+func (h Handlers) RPC(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+
+	body = bytes.TrimSpace(body)
+
+	// A single request is answered with a single response.
+	if len(body) == 0 || body[0] != '[' {
+		resp := h.process(ctx, body)
+		if resp == nil {
+			return web.Respond(ctx, w, nil, http.StatusNoContent)
+		}
+		return web.Respond(ctx, w, resp, http.StatusOK)
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		return web.Respond(ctx, w, errorResponse(null, codeParseError, "parse error"), http.StatusOK)
+	}
+
+	if len(batch) == 0 {
+		return web.Respond(ctx, w, errorResponse(null, codeInvalidRequest, "empty batch"), http.StatusOK)
+	}
+
+	resps := make([]*response, 0, len(batch))
+	for _, raw := range batch {
+		if resp := h.process(ctx, raw); resp != nil {
+			resps = append(resps, resp)
+		}
+	}
+
+	// A batch of only notifications doesn't get a response.
+	if len(resps) == 0 {
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+
+	return web.Respond(ctx, w, resps, http.StatusOK)
+}
+
+// process executes a single JSON-RPC request and returns the response, or
+// nil if the request is a notification.
+func (h Handlers) process(ctx context.Context, raw json.RawMessage) *response {
+	if !json.Valid(raw) {
+		return errorResponse(null, codeParseError, "parse error")
+	}
+
+	var req request
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return errorResponse(null, codeInvalidRequest, "invalid request")
+	}
+
+	id := req.ID
+	if id == nil {
+		id = null
+	}
+
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return errorResponse(id, codeInvalidRequest, "invalid request")
+	}
+
+	result, err := h.call(req.Method, req.Params)
+
+	// Notifications don't get a response, even if they failed.
+	if req.ID == nil {
+		return nil
+	}
+
+	if err != nil {
+		rpcErr, ok := err.(*rpcError)
+		if !ok {
+			h.Log.Errorw("rpc", "traceid", web.GetTraceID(ctx), "method", req.Method, "ERROR", err)
+			rpcErr = &rpcError{Code: codeInternalError, Message: "internal error"}
+		}
+		return errorResponse(id, rpcErr.Code, rpcErr.Message)
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		h.Log.Errorw("rpc", "traceid", web.GetTraceID(ctx), "method", req.Method, "ERROR", err)
+		return errorResponse(id, codeInternalError, "internal error")
+	}
+
+	return &response{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result:  data,
+	}
+}
+
+// call dispatches the method to its implementation.
+func (h Handlers) call(method string, params json.RawMessage) (any, error) {
+	switch method {
+	case "eth_chainId":
+		return h.chainID(params)
+	case "eth_blockNumber":
+		return h.blockNumber(params)
+	case "eth_getBalance":
+		return h.getBalance(params)
+	case "eth_getTransactionCount":
+		return h.getTransactionCount(params)
+	case "eth_getBlockByNumber":
+		return h.getBlockByNumber(params)
+	case "eth_getTransactionByHash":
+		return h.getTransactionByHash(params)
+	}
+
+	return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %s not found", method)}
+}
+
+// =============================================================================
+
+// chainID returns the chain id from the genesis file.
+func (h Handlers) chainID(params json.RawMessage) (any, error) {
+	if _, err := parseParams(params, 0, 0); err != nil {
+		return nil, err
+	}
+
+	return hexutil.EncodeUint64(uint64(h.Genesis.ChainID)), nil
+}
+
+// blockNumber returns the number of the latest block. The node doesn't
+// store blocks yet, so the chain is always at genesis.
+func (h Handlers) blockNumber(params json.RawMessage) (any, error) {
+	if _, err := parseParams(params, 0, 0); err != nil {
+		return nil, err
+	}
+
+	return hexutil.EncodeUint64(0), nil
+}
+
+// getBalance returns the balance of the account. Unknown accounts have a
+// balance of zero.
+func (h Handlers) getBalance(params json.RawMessage) (any, error) {
+	account, err := h.queryAccount(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return hexutil.EncodeUint64(account.Balance), nil
+}
+
+// getTransactionCount returns the nonce of the account. Unknown accounts
+// have a nonce of zero.
+func (h Handlers) getTransactionCount(params json.RawMessage) (any, error) {
+	account, err := h.queryAccount(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return hexutil.EncodeUint64(account.Nonce), nil
+}
+
+// getBlockByNumber returns the block for the specified number. The node
+// doesn't store blocks yet, so no block is ever found.
+func (h Handlers) getBlockByNumber(params json.RawMessage) (any, error) {
+	args, err := parseParams(params, 2, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := parseBlockTag(args[0]); err != nil {
+		return nil, err
+	}
+
+	var fullTx bool
+	if err := json.Unmarshal(args[1], &fullTx); err != nil {
+		return nil, invalidParams("full transactions flag must be a boolean")
+	}
+
+	return nil, nil
+}
+
+// getTransactionByHash returns the transaction for the specified hash. The
+// node doesn't store transactions yet, so no transaction is ever found.
+func (h Handlers) getTransactionByHash(params json.RawMessage) (any, error) {
+	args, err := parseParams(params, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	var hash string
+	if err := json.Unmarshal(args[0], &hash); err != nil {
+		return nil, invalidParams("hash must be a string")
+	}
+
+	b, err := hexutil.Decode(hash)
+	if err != nil || len(b) != common.HashLength {
+		return nil, invalidParams("hash must be 32 bytes of hex")
+	}
+
+	return nil, nil
+}
+
+// queryAccount parses the account and block parameters and returns the
+// account's information from the database.
+func (h Handlers) queryAccount(params json.RawMessage) (database.Account, error) {
+	args, err := parseParams(params, 1, 2)
+	if err != nil {
+		return database.Account{}, err
+	}
+
+	var address string
+	if err := json.Unmarshal(args[0], &address); err != nil {
+		return database.Account{}, invalidParams("address must be a string")
+	}
+
+	accountID, err := database.ToAccountID(address)
+	if err != nil {
+		return database.Account{}, invalidParams(err.Error())
+	}
+
+	// The block is optional and every block maps onto the current state.
+	if len(args) == 2 {
+		if err := parseBlockTag(args[1]); err != nil {
+			return database.Account{}, err
+		}
+	}
+
+	account, err := h.DB.Query(accountID)
+	if err != nil {
+		return database.Account{AccountID: accountID}, nil
+	}
+
+	return account, nil
+}
+
+// =============================================================================
+
+// parseParams splits the positional params into their raw values and checks
+// the number of values provided.
+func parseParams(params json.RawMessage, min int, max int) ([]json.RawMessage, error) {
+	var args []json.RawMessage
+
+	params = bytes.TrimSpace(params)
+	if len(params) > 0 && !bytes.Equal(params, null) {
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, invalidParams("params must be an array")
+		}
+	}
+
+	if len(args) < min || len(args) > max {
+		return nil, invalidParams(fmt.Sprintf("expected between %d and %d params, got %d", min, max, len(args)))
+	}
+
+	return args, nil
+}
+
+// parseBlockTag validates a block number or tag.
+func parseBlockTag(raw json.RawMessage) error {
+	var tag string
+	if err := json.Unmarshal(raw, &tag); err != nil {
+		return invalidParams("block must be a string")
+	}
+
+	switch tag {
+	case "latest", "earliest", "pending", "safe", "finalized":
+		return nil
+	}
+
+	if _, err := hexutil.DecodeUint64(tag); err != nil {
+		return invalidParams(fmt.Sprintf("invalid block %q", tag))
+	}
+
+	return nil
+}
+
+// invalidParams constructs an invalid params error.
+func invalidParams(msg string) error {
+	return &rpcError{Code: codeInvalidParams, Message: msg}
+}
+
+// errorResponse constructs an error response for the request id.
+func errorResponse(id json.RawMessage, code int, msg string) *response {
+	return &response{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error:   &rpcError{Code: code, Message: msg},
+	}
+}
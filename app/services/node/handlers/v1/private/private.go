@@ -14,11 +14,14 @@ type Handlers struct {
 	Log *zap.SugaredLogger
 }
 
+// Status represents the response from the sample endpoint.
+type Status struct {
+	Status string
+}
+
 // Sample just provides a starting point for the class.
 func (h Handlers) Sample(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-	resp := struct {
-		Status string
-	}{
+	resp := Status{
 		Status: "OK",
 	}
 
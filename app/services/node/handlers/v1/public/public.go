@@ -3,24 +3,115 @@ package public
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
+	v1 "github.com/ardanlabs/blockchain/business/web/v1"
+	"github.com/ardanlabs/blockchain/foundation/blockchain/database"
+	"github.com/ardanlabs/blockchain/foundation/events"
 	"github.com/ardanlabs/blockchain/foundation/web"
 	"go.uber.org/zap"
 )
 
 // Handlers manages the set of bar ledger endpoints.
 type Handlers struct {
-	Log *zap.SugaredLogger
+	Log  *zap.SugaredLogger
+	Evts *events.Events
+}
+
+// Status represents the response from the sample endpoint.
+type Status struct {
+	Status string
 }
 
 // Sample just provides a starting point for the class.
 func (h Handlers) Sample(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-	resp := struct {
-		Status string
-	}{
+	resp := Status{
 		Status: "OK",
 	}
 
 	return web.Respond(ctx, w, resp, http.StatusOK)
 }
+
+// Events streams node events to the client using server-sent events. The
+// stream can be filtered by a comma separated list of event types and by
+// the account touched by the event.
+func (h Handlers) Events(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var filter events.Filter
+
+	if types := r.URL.Query().Get("type"); types != "" {
+		filter.Types = strings.Split(types, ",")
+	}
+
+	if account := r.URL.Query().Get("account"); account != "" {
+		accountID, err := database.ToAccountID(account)
+		if err != nil {
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		}
+		filter.Account = string(accountID)
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return errors.New("streaming not supported")
+	}
+
+	// The stream lives longer than the server's write timeout allows.
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		h.Log.Infow("events", "traceid", web.GetTraceID(ctx), "status", "unable to clear write deadline", "ERROR", err)
+	}
+
+	const buffer = 100
+	sub := h.Evts.Subscribe(filter, buffer)
+	defer h.Evts.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	web.SetStatusCode(ctx, http.StatusOK)
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+
+	// Once the stream has started, errors can't be sent back to the client
+	// so the handler just ends the stream.
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return nil
+			}
+			flusher.Flush()
+
+		case evt, ok := <-sub.C:
+			if !ok {
+				if errors.Is(sub.Err(), events.ErrLagged) {
+					fmt.Fprintf(w, "event: lagged\ndata: %q\n\n", sub.Err().Error())
+					flusher.Flush()
+				}
+				return nil
+			}
+
+			data, err := json.Marshal(evt)
+			if err != nil {
+				h.Log.Errorw("events", "traceid", web.GetTraceID(ctx), "ERROR", err)
+				continue
+			}
+
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", evt.Type, data); err != nil {
+				return nil
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -7,6 +7,8 @@ import (
 
 	"github.com/ardanlabs/blockchain/app/services/node/handlers/v1/private"
 	"github.com/ardanlabs/blockchain/app/services/node/handlers/v1/public"
+	"github.com/ardanlabs/blockchain/business/web/v1/mid"
+	"github.com/ardanlabs/blockchain/foundation/events"
 	"github.com/ardanlabs/blockchain/foundation/web"
 	"go.uber.org/zap"
 )
@@ -15,16 +17,36 @@ const version = "v1"
 
 // Config contains all the mandatory systems required by handlers.
 type Config struct {
-	Log *zap.SugaredLogger
+	Log       *zap.SugaredLogger
+	Evts      *events.Events
+	RateLimit mid.RateLimitConfig
 }
 
 // PublicRoutes binds all the version 1 public routes.
 func PublicRoutes(app *web.App, cfg Config) {
 	pbl := public.Handlers{
-		Log: cfg.Log,
+		Log:  cfg.Log,
+		Evts: cfg.Evts,
 	}
 
-	app.Handle(http.MethodGet, version, "/sample", pbl.Sample)
+	// The public routes share a single rate limit per client.
+	rl := mid.RateLimit(cfg.RateLimit)
+
+	app.Handle(http.MethodGet, version, "/sample", pbl.Sample, rl).Document(web.Doc{
+		Summary:  "Sample endpoint",
+		Response: public.Status{},
+	})
+
+	app.Handle(http.MethodGet, version, "/events", pbl.Events, rl).Document(web.Doc{
+		Summary:     "Stream node events",
+		Description: "Streams events as server-sent events until the client disconnects.",
+		Query: map[string]string{
+			"type":    "Comma separated list of event types to receive.",
+			"account": "Only receive events touching this account.",
+		},
+		Response:    events.Event{},
+		ContentType: "text/event-stream",
+	})
 }
 
 // PrivateRoutes binds all the version 1 private routes.
@@ -33,5 +55,8 @@ func PrivateRoutes(app *web.App, cfg Config) {
 		Log: cfg.Log,
 	}
 
-	app.Handle(http.MethodGet, version, "/node/sample", prv.Sample)
+	app.Handle(http.MethodGet, version, "/node/sample", prv.Sample).Document(web.Doc{
+		Summary:  "Sample endpoint",
+		Response: private.Status{},
+	})
 }
@@ -7,11 +7,21 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/ardanlabs/blockchain/app/services/node/handlers"
+	"github.com/ardanlabs/blockchain/app/services/node/handlers/debug/checkgrp"
+	"github.com/ardanlabs/blockchain/business/web/metrics"
+	"github.com/ardanlabs/blockchain/business/web/v1/mid"
+	"github.com/ardanlabs/blockchain/foundation/blockchain/database"
+	"github.com/ardanlabs/blockchain/foundation/blockchain/genesis"
+	"github.com/ardanlabs/blockchain/foundation/blockchain/peer"
+	"github.com/ardanlabs/blockchain/foundation/events"
 	"github.com/ardanlabs/blockchain/foundation/logger"
+	"github.com/ardanlabs/blockchain/foundation/tracer"
 	"github.com/ardanlabs/conf/v3"
 	"go.uber.org/zap"
 )
@@ -19,62 +29,134 @@ import (
 // build is the git version of this program. It is set using build flags in the makefile.
 var build = "develop"
 
-func main() {
-
-	// Construct the application logger.
-	log, err := logger.New("NODE")
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+// config is all the configuration for the application. Configuration values
+// will be passed through the application as individual values.
+type config struct {
+	conf.Version
+	Web struct {
+		ReadTimeout     time.Duration `conf:"default:5s"`
+		WriteTimeout    time.Duration `conf:"default:10s"`
+		IdleTimeout     time.Duration `conf:"default:120s"`
+		ShutdownTimeout time.Duration `conf:"default:20s"`
+		DebugHost       string        `conf:"default:0.0.0.0:7080"`
+		PublicHost      string        `conf:"default:0.0.0.0:8080"`
+		PrivateHost     string        `conf:"default:0.0.0.0:9080"`
+		MaxBodySize     int64         `conf:"default:1048576"`
+		RateLimit       float64       `conf:"default:10"`
+		RateBurst       int           `conf:"default:20"`
+		TrustedProxies  []string
 	}
-	defer log.Sync()
-
-	// Perform the startup and shutdown sequence.
-	if err := run(log); err != nil {
-		log.Errorw("startup", "ERROR", err)
-		log.Sync()
-		os.Exit(1)
+	Cors struct {
+		AllowedOrigins        []string      `conf:"default:*"`
+		AllowedHeaders        []string      `conf:"default:Origin;Accept;Content-Type;Content-Length;Accept-Encoding;X-CSRF-Token;Authorization"`
+		AllowCredentials      bool          `conf:"default:false"`
+		MaxAge                time.Duration `conf:"default:12h"`
+		PrivateAllowedOrigins []string
+	}
+	Tracer struct {
+		Exporter string `conf:"default:none"` // none, stdout or file
+		FilePath string // defaults to traces.json in the state db path
+	}
+	Log struct {
+		Level              string   `conf:"default:info"`
+		Levels             []string // name=level for subsystem loggers
+		SamplingInitial    int      `conf:"default:100"`
+		SamplingThereafter int      `conf:"default:100"`
+
+		// File also writes the logs to the file, rotating it once it
+		// reaches FileMaxSize megabytes or is FileMaxAge old.
+		File           string
+		FileMaxSize    int           `conf:"default:100"`
+		FileMaxAge     time.Duration `conf:"default:24h"`
+		FileMaxBackups int           `conf:"default:7"`
+		FileCompress   bool          `conf:"default:true"`
+	}
+	State struct {
+		DBPath string `conf:"default:zblock/miner1/"`
+	}
+	Peer struct {
+		BanScore    int           `conf:"default:100"`
+		BanDuration time.Duration `conf:"default:1h"`
+		AllowedIDs  []string
+		AuthMaxSkew time.Duration `conf:"default:30s"`
 	}
 }
 
-func run(log *zap.SugaredLogger) error {
+func main() {
 
 	// =========================================================================
 	// Configuration
 
-	// This is all the configuration for the application and the default values.
-	// Configuration values will be passed through the application as individual
-	// values.
-	cfg := struct {
-		conf.Version
-		Web struct {
-			ReadTimeout     time.Duration `conf:"default:5s"`
-			WriteTimeout    time.Duration `conf:"default:10s"`
-			IdleTimeout     time.Duration `conf:"default:120s"`
-			ShutdownTimeout time.Duration `conf:"default:20s"`
-			DebugHost       string        `conf:"default:0.0.0.0:7080"`
-			PublicHost      string        `conf:"default:0.0.0.0:8080"`
-			PrivateHost     string        `conf:"default:0.0.0.0:9080"`
-		}
-	}{
+	// The defaults are set here and Parse will look for any overriding values
+	// in environment variables and command line flags. The configuration is
+	// parsed before the logger is constructed since it holds its settings.
+	cfg := config{
 		Version: conf.Version{
 			Build: build,
 			Desc:  "copyright information here",
 		},
 	}
 
-	// Parse will set the defaults and then look for any overriding values
-	// in environment variables and command line flags.
 	const prefix = "NODE"
 	help, err := conf.Parse(prefix, &cfg)
 	if err != nil {
 		if errors.Is(err, conf.ErrHelpWanted) {
 			fmt.Println(help)
-			return nil
+			return
+		}
+		fmt.Println("parsing config:", err)
+		os.Exit(1)
+	}
+
+	// Construct the application logger.
+	log, err := newLogger(cfg)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	defer log.Close()
+
+	// Perform the startup and shutdown sequence.
+	if err := run(log, cfg); err != nil {
+		log.Errorw("startup", "ERROR", err)
+		log.Close()
+		os.Exit(1)
+	}
+}
+
+// newLogger constructs the logger from the configuration. Subsystem levels
+// are provided as name=level pairs.
+func newLogger(cfg config) (*logger.Logger, error) {
+	levels := make(map[string]string)
+	for _, pair := range cfg.Log.Levels {
+		name, level, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid subsystem log level %q", pair)
 		}
-		return fmt.Errorf("parsing config: %w", err)
+		levels[strings.TrimSpace(name)] = strings.TrimSpace(level)
 	}
 
+	outputs := []string{"stdout"}
+	if cfg.Log.File != "" {
+		outputs = append(outputs, logger.RotateURL(cfg.Log.File, logger.RotateConfig{
+			MaxSize:    cfg.Log.FileMaxSize,
+			MaxAge:     cfg.Log.FileMaxAge,
+			MaxBackups: cfg.Log.FileMaxBackups,
+			Compress:   cfg.Log.FileCompress,
+		}))
+	}
+
+	return logger.New("NODE", logger.Config{
+		Outputs:            outputs,
+		Level:              cfg.Log.Level,
+		Levels:             levels,
+		SamplingInitial:    cfg.Log.SamplingInitial,
+		SamplingThereafter: cfg.Log.SamplingThereafter,
+	})
+}
+
+func run(log *logger.Logger, cfg config) error {
+
 	// =========================================================================
 	// App Starting
 
@@ -95,6 +177,115 @@ func run(log *zap.SugaredLogger) error {
 	}
 	log.Infow("startup", "config", out)
 
+	// =========================================================================
+	// Blockchain Support
+
+	// Load the genesis file for the blockchain settings and origin balances.
+	gen, err := genesis.Load()
+	if err != nil {
+		return fmt.Errorf("loading genesis: %w", err)
+	}
+
+	// The database holds the account information for the node.
+	db, err := database.New(gen)
+	if err != nil {
+		return fmt.Errorf("constructing database: %w", err)
+	}
+
+	// =========================================================================
+	// Node Key Support
+
+	// The node key identifies this node to its peers and is used to sign
+	// node to node requests. A new key is generated on first start.
+	nodeKey, err := peer.LoadOrGenerateKey(filepath.Join(cfg.State.DBPath, "node.ecdsa"))
+	if err != nil {
+		return fmt.Errorf("loading node key: %w", err)
+	}
+
+	log.Infow("startup", "status", "node key loaded", "nodeid", peer.NodeID(nodeKey))
+
+	// =========================================================================
+	// Tracing Support
+
+	// Spans are exported so a request can be followed across several nodes.
+	// The trace context is propagated even when no exporter is configured.
+	var exporter tracer.Exporter
+	switch cfg.Tracer.Exporter {
+	case "none":
+	case "stdout":
+		exporter = tracer.NewWriterExporter(os.Stdout)
+	case "file":
+		path := cfg.Tracer.FilePath
+		if path == "" {
+			path = filepath.Join(cfg.State.DBPath, "traces.json")
+		}
+
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("opening trace file: %w", err)
+		}
+		defer f.Close()
+
+		exporter = tracer.NewWriterExporter(f)
+	default:
+		return fmt.Errorf("unknown tracer exporter %q", cfg.Tracer.Exporter)
+	}
+
+	trc := tracer.New("NODE:"+peer.NodeID(nodeKey), exporter, func(err error) {
+		log.Errorw("tracer", "status", "unable to export span", "ERROR", err)
+	})
+
+	// =========================================================================
+	// Peer Support
+
+	// The peer scores track misbehaving peers and persist their bans in the
+	// node's database path.
+	peers, err := peer.NewScores(cfg.State.DBPath, cfg.Peer.BanScore, cfg.Peer.BanDuration)
+	if err != nil {
+		return fmt.Errorf("constructing peer scores: %w", err)
+	}
+
+	// =========================================================================
+	// Events Support
+
+	// The events value delivers what happens inside the node to the clients
+	// that subscribed through the public API.
+	evts := events.New()
+
+	// =========================================================================
+	// Metrics Support
+
+	// Subsystems report their state as gauges that are read on every scrape
+	// of the metrics endpoint.
+	gauges := []struct {
+		name string
+		help string
+		fn   func() float64
+	}{
+		{"node_peers", "Number of peers with a score.", func() float64 {
+			return float64(len(peers.List()))
+		}},
+		{"node_peers_banned", "Number of peers currently banned.", func() float64 {
+			var banned int
+			now := time.Now()
+			for _, st := range peers.List() {
+				if st.IsBanned(now) {
+					banned++
+				}
+			}
+			return float64(banned)
+		}},
+		{"node_event_subscribers", "Number of clients subscribed to node events.", func() float64 {
+			return float64(evts.Count())
+		}},
+	}
+
+	for _, g := range gauges {
+		if err := metrics.RegisterGauge(g.name, g.help, g.fn); err != nil {
+			return fmt.Errorf("registering gauge: %w", err)
+		}
+	}
+
 	// =========================================================================
 	// Start Debug Service
 
@@ -104,7 +295,15 @@ func run(log *zap.SugaredLogger) error {
 	// related endpoints. This includes the standard library endpoints.
 
 	// Construct the mux for the debug calls.
-	debugMux := handlers.DebugMux(build, log)
+	debugMux := handlers.DebugMux(handlers.DebugMuxConfig{
+		Build:  build,
+		Log:    log.Named("debug"),
+		Logger: log,
+		Peers:  peers,
+		Checks: map[string]checkgrp.Check{
+			"storage": checkgrp.StorageWritable(cfg.State.DBPath),
+		},
+	})
 
 	// Start the service listening for debug requests.
 	// Not concerned with shutting this down with load shedding.
@@ -126,15 +325,41 @@ func run(log *zap.SugaredLogger) error {
 	// buffered channel so the goroutine can exit if we don't collect this error.
 	serverErrors := make(chan error, 1)
 
+	// The web subsystem logs through its own logger so its level can be
+	// changed independently.
+	webLog := log.Named("web")
+
 	// =========================================================================
 	// Start Public Service
 
 	log.Infow("startup", "status", "initializing V1 public API support")
 
+	// The trusted proxies decide when X-Forwarded-For identifies the client.
+	proxies, err := mid.ParseTrustedProxies(cfg.Web.TrustedProxies)
+	if err != nil {
+		return fmt.Errorf("parsing trusted proxies: %w", err)
+	}
+
 	// Construct the mux for the public API calls.
 	publicMux := handlers.PublicMux(handlers.MuxConfig{
-		Shutdown: shutdown,
-		Log:      log,
+		Shutdown:    shutdown,
+		Log:         webLog,
+		Tracer:      trc,
+		MaxBodySize: cfg.Web.MaxBodySize,
+		Genesis:     gen,
+		DB:          db,
+		Evts:        evts,
+		RateLimit: mid.RateLimitConfig{
+			Rate:           cfg.Web.RateLimit,
+			Burst:          cfg.Web.RateBurst,
+			TrustedProxies: proxies,
+		},
+		Cors: mid.CorsConfig{
+			AllowedOrigins:   cfg.Cors.AllowedOrigins,
+			AllowedHeaders:   cfg.Cors.AllowedHeaders,
+			AllowCredentials: cfg.Cors.AllowCredentials,
+			MaxAge:           cfg.Cors.MaxAge,
+		},
 	})
 
 	// Construct a server to service the requests against the mux.
@@ -144,7 +369,7 @@ func run(log *zap.SugaredLogger) error {
 		ReadTimeout:  cfg.Web.ReadTimeout,
 		WriteTimeout: cfg.Web.WriteTimeout,
 		IdleTimeout:  cfg.Web.IdleTimeout,
-		ErrorLog:     zap.NewStdLog(log.Desugar()),
+		ErrorLog:     zap.NewStdLog(webLog.Desugar()),
 	}
 
 	// Start the service listening for api requests.
@@ -160,8 +385,19 @@ func run(log *zap.SugaredLogger) error {
 
 	// Construct the mux for the private API calls.
 	privateMux := handlers.PrivateMux(handlers.MuxConfig{
-		Shutdown: shutdown,
-		Log:      log,
+		Shutdown:     shutdown,
+		Log:          webLog,
+		Tracer:       trc,
+		MaxBodySize:  cfg.Web.MaxBodySize,
+		Peers:        peers,
+		AllowedNodes: cfg.Peer.AllowedIDs,
+		AuthMaxSkew:  cfg.Peer.AuthMaxSkew,
+		Cors: mid.CorsConfig{
+			AllowedOrigins:   cfg.Cors.PrivateAllowedOrigins,
+			AllowedHeaders:   cfg.Cors.AllowedHeaders,
+			AllowCredentials: cfg.Cors.AllowCredentials,
+			MaxAge:           cfg.Cors.MaxAge,
+		},
 	})
 
 	// Construct a server to service the requests against the mux.
@@ -171,7 +407,7 @@ func run(log *zap.SugaredLogger) error {
 		ReadTimeout:  cfg.Web.ReadTimeout,
 		WriteTimeout: cfg.Web.WriteTimeout,
 		IdleTimeout:  cfg.Web.IdleTimeout,
-		ErrorLog:     zap.NewStdLog(log.Desugar()),
+		ErrorLog:     zap.NewStdLog(webLog.Desugar()),
 	}
 
 	// Start the service listening for api requests.
@@ -203,6 +439,9 @@ func run(log *zap.SugaredLogger) error {
 			return fmt.Errorf("could not stop private service gracefully: %w", err)
 		}
 
+		// Close the event streams so those requests can complete.
+		evts.Shutdown()
+
 		// Give outstanding requests a deadline for completion.
 		ctx, cancelPri := context.WithTimeout(context.Background(), cfg.Web.ShutdownTimeout)
 		defer cancelPri()
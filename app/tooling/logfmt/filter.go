@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// tsLayout is the layout of the timestamps written by the logger.
+const tsLayout = "2006-01-02T15:04:05.000Z0700"
+
+// grepFlags collects the repeated -grep flags.
+type grepFlags []string
+
+// String implements the flag.Value interface.
+func (g *grepFlags) String() string {
+	return strings.Join(*g, ",")
+}
+
+// Set implements the flag.Value interface.
+func (g *grepFlags) Set(value string) error {
+	if !strings.Contains(value, "=") {
+		return fmt.Errorf("grep must be key=value, got %q", value)
+	}
+	*g = append(*g, value)
+	return nil
+}
+
+// =============================================================================
+
+// filter decides which log lines are written.
+type filter struct {
+	service string
+	level   *zapcore.Level
+	traceID string
+	since   time.Time
+	until   time.Time
+	grep    map[string]string
+}
+
+// newFilter constructs a filter from the flag values.
+func newFilter(service string, level string, traceID string, since string, until string, grep grepFlags) (filter, error) {
+	f := filter{
+		service: service,
+		traceID: traceID,
+		grep:    make(map[string]string),
+	}
+
+	if level != "" {
+		var lvl zapcore.Level
+		if err := lvl.UnmarshalText([]byte(level)); err != nil {
+			return filter{}, fmt.Errorf("parsing level: %w", err)
+		}
+		f.level = &lvl
+	}
+
+	var err error
+	if f.since, err = parseTime(since); err != nil {
+		return filter{}, fmt.Errorf("parsing since: %w", err)
+	}
+	if f.until, err = parseTime(until); err != nil {
+		return filter{}, fmt.Errorf("parsing until: %w", err)
+	}
+
+	for _, kv := range grep {
+		key, value, _ := strings.Cut(kv, "=")
+		f.grep[key] = value
+	}
+
+	return f, nil
+}
+
+// active reports whether any filter is set. Lines that aren't JSON are only
+// written when no filter is set.
+func (f filter) active() bool {
+	return f.service != "" || f.level != nil || f.traceID != "" ||
+		!f.since.IsZero() || !f.until.IsZero() || len(f.grep) > 0
+}
+
+// match reports whether the log line passes every filter.
+func (f filter) match(m map[string]any) bool {
+	if f.service != "" && m["service"] != f.service {
+		return false
+	}
+
+	if f.level != nil {
+		var lvl zapcore.Level
+		s, _ := m["level"].(string)
+		if err := lvl.UnmarshalText([]byte(s)); err != nil || lvl < *f.level {
+			return false
+		}
+	}
+
+	if f.traceID != "" && m["traceid"] != f.traceID {
+		return false
+	}
+
+	if !f.since.IsZero() || !f.until.IsZero() {
+		s, _ := m["ts"].(string)
+		ts, err := time.Parse(tsLayout, s)
+		if err != nil {
+			return false
+		}
+		if !f.since.IsZero() && ts.Before(f.since) {
+			return false
+		}
+		if !f.until.IsZero() && ts.After(f.until) {
+			return false
+		}
+	}
+
+	for key, value := range f.grep {
+		v, exists := m[key]
+		if !exists || !strings.Contains(fmt.Sprintf("%v", v), value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseTime parses a time in RFC3339 format or a duration that is
+// subtracted from the current time, like 15m.
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+
+	return time.Parse(time.RFC3339, s)
+}
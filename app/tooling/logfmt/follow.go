@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// followInterval is how often the file is checked for new data.
+const followInterval = 250 * time.Millisecond
+
+// follower reads a file like tail -f. At the end of the file it waits for
+// more data instead of returning io.EOF. If the file is rotated, reading
+// continues with the new file once the old one is read.
+type follower struct {
+	path string
+	file *os.File
+}
+
+// newFollower opens the file for following.
+func newFollower(path string) (*follower, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &follower{path: path, file: f}, nil
+}
+
+// Read implements the io.Reader interface.
+func (f *follower) Read(p []byte) (int, error) {
+	for {
+		n, err := f.file.Read(p)
+		if n > 0 || !errors.Is(err, io.EOF) {
+			return n, err
+		}
+
+		rotated, err := f.rotated()
+		if err != nil {
+			return 0, err
+		}
+
+		if !rotated {
+			time.Sleep(followInterval)
+			continue
+		}
+
+		// Data may have been written to the old file between the read
+		// and the rotation, so read it once more before switching.
+		n, err = f.file.Read(p)
+		if n > 0 || !errors.Is(err, io.EOF) {
+			return n, err
+		}
+
+		file, err := os.Open(f.path)
+		if err != nil {
+			return 0, err
+		}
+
+		f.file.Close()
+		f.file = file
+	}
+}
+
+// rotated reports whether the file at the path is no longer the one being
+// read.
+func (f *follower) rotated() (bool, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+
+		// The file can be missing for a moment while it's rotated.
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	current, err := f.file.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	return !os.SameFile(info, current), nil
+}
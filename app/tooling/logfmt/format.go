@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Set of output formats.
+const (
+	formatPretty = "pretty"
+	formatLogfmt = "logfmt"
+	formatJSON   = "json"
+)
+
+// Set of ANSI escape codes used to colour the level.
+const (
+	colorReset   = "\x1b[0m"
+	colorRed     = "\x1b[31m"
+	colorYellow  = "\x1b[33m"
+	colorBlue    = "\x1b[34m"
+	colorMagenta = "\x1b[35m"
+)
+
+// knownKeys are written first, in this order, by every format.
+var knownKeys = []string{"service", "ts", "level", "traceid", "caller", "msg"}
+
+// =============================================================================
+
+// formatter converts a log line into the output format.
+type formatter struct {
+	format string
+	color  bool
+}
+
+// newFormatter constructs a formatter for the format. The color mode is
+// auto, always or never. Auto colours the output when it's a terminal.
+func newFormatter(format string, color string) (formatter, error) {
+	switch format {
+	case formatPretty, formatLogfmt, formatJSON:
+	default:
+		return formatter{}, fmt.Errorf("unknown format %q", format)
+	}
+
+	f := formatter{
+		format: format,
+	}
+
+	switch color {
+	case "auto":
+		f.color = isTerminal(os.Stdout)
+	case "always":
+		f.color = true
+	case "never":
+	default:
+		return formatter{}, fmt.Errorf("unknown color mode %q", color)
+	}
+
+	// The json format passes lines through so it's never coloured.
+	if format == formatJSON {
+		f.color = false
+	}
+
+	return f, nil
+}
+
+// line returns the log line in the output format. The original line is
+// passed through for the json format.
+func (f formatter) line(raw string, m map[string]any) string {
+	switch f.format {
+	case formatLogfmt:
+		return f.logfmt(m)
+	case formatJSON:
+		return raw
+	}
+
+	return f.pretty(m)
+}
+
+// pretty writes the known keys as a prefix and the rest as key[value].
+func (f formatter) pretty(m map[string]any) string {
+	var b strings.Builder
+
+	// I like always having a traceid present in the logs.
+	traceID := "00000000000000000000000000000000"
+	if v, ok := m["traceid"]; ok {
+		traceID = fmt.Sprintf("%v", v)
+	}
+
+	// Build out the know portions of the log in the order
+	// I want them in.
+	b.WriteString(fmt.Sprintf("%s: %s: %s: %s: %s: %s",
+		m["service"],
+		m["ts"],
+		f.level(m["level"]),
+		traceID,
+		m["caller"],
+		m["msg"],
+	))
+
+	// Add the rest of the keys in sorted order ignoring the ones we
+	// already added for the log.
+	for _, k := range extraKeys(m) {
+		b.WriteString(fmt.Sprintf(": %s[%v]", k, m[k]))
+	}
+
+	return b.String()
+}
+
+// logfmt writes every key as key=value with the known keys first.
+func (f formatter) logfmt(m map[string]any) string {
+	var pairs []string
+
+	for _, k := range knownKeys {
+		v, exists := m[k]
+		if !exists {
+			continue
+		}
+
+		value := logfmtValue(v)
+		if k == "level" {
+			value = f.level(value)
+		}
+		pairs = append(pairs, k+"="+value)
+	}
+
+	for _, k := range extraKeys(m) {
+		pairs = append(pairs, k+"="+logfmtValue(m[k]))
+	}
+
+	return strings.Join(pairs, " ")
+}
+
+// level returns the level, coloured if colour is enabled.
+func (f formatter) level(v any) string {
+	level := fmt.Sprintf("%v", v)
+	if !f.color {
+		return level
+	}
+
+	var color string
+	switch level {
+	case "debug":
+		color = colorMagenta
+	case "info":
+		color = colorBlue
+	case "warn":
+		color = colorYellow
+	case "error", "dpanic", "panic", "fatal":
+		color = colorRed
+	default:
+		return level
+	}
+
+	return color + level + colorReset
+}
+
+// =============================================================================
+
+// extraKeys returns the keys that aren't known keys, sorted.
+func extraKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	extra := keys[:0]
+	for _, k := range keys {
+		if !isKnownKey(k) {
+			extra = append(extra, k)
+		}
+	}
+
+	return extra
+}
+
+// isKnownKey reports whether the key is written first.
+func isKnownKey(key string) bool {
+	for _, k := range knownKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// logfmtValue formats a value for the logfmt format. Strings are quoted
+// when needed and anything else is written as JSON.
+func logfmtValue(v any) string {
+	s, ok := v.(string)
+	if !ok {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(data)
+	}
+
+	if s == "" || strings.ContainsAny(s, " =\"\t\n") {
+		return fmt.Sprintf("%q", s)
+	}
+
+	return s
+}
+
+// isTerminal reports whether the file is a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
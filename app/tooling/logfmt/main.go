@@ -4,80 +4,126 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"strings"
 )
 
-var service string
+var (
+	service string
+	level   string
+	traceID string
+	since   string
+	until   string
+	grep    grepFlags
+	file    string
+	follow  bool
+	format  string
+	color   string
+	rpt     bool
+)
 
 func init() {
 	flag.StringVar(&service, "service", "", "filter which service to see")
+	flag.StringVar(&level, "level", "", "filter logs below this level")
+	flag.StringVar(&traceID, "traceid", "", "filter which trace to see")
+	flag.StringVar(&since, "since", "", "filter logs before this RFC3339 time or duration ago")
+	flag.StringVar(&until, "until", "", "filter logs after this RFC3339 time or duration ago")
+	flag.Var(&grep, "grep", "filter logs where key contains value, as key=value (repeatable)")
+	flag.StringVar(&file, "file", "", "read the logs from this file instead of standard input")
+	flag.BoolVar(&follow, "follow", false, "keep reading the file as it grows like tail -f")
+	flag.StringVar(&format, "format", formatPretty, "output format: pretty, logfmt or json")
+	flag.StringVar(&color, "color", "auto", "colour the level: auto, always or never")
+	flag.BoolVar(&rpt, "report", false, "summarize the requests per path instead of writing the logs")
 }
 
 func main() {
 	flag.Parse()
-	var b strings.Builder
 
-	// Scan standard input for log data per line.
-	scanner := bufio.NewScanner(os.Stdin)
-	for scanner.Scan() {
-		s := scanner.Text()
+	filter, err := newFilter(service, level, traceID, since, until, grep)
+	if err != nil {
+		log.Fatalln(err)
+	}
 
-		// Convert the JSON to a map for processing.
-		m := make(map[string]any)
-		err := json.Unmarshal([]byte(s), &m)
+	var input io.Reader = os.Stdin
+	switch {
+	case follow && file == "":
+		log.Fatalln("follow requires a file")
+
+	case follow && rpt:
+		log.Fatalln("report can't be used with follow")
+
+	case follow:
+		f, err := newFollower(file)
 		if err != nil {
-			if service == "" {
-				fmt.Println(s)
-			}
-			continue
+			log.Fatalln(err)
 		}
+		input = f
 
-		// If a service filter was provided, check.
-		if service != "" && m["service"] != service {
-			continue
+	case file != "":
+		f, err := os.Open(file)
+		if err != nil {
+			log.Fatalln(err)
 		}
+		defer f.Close()
+		input = f
+	}
+
+	fmtr, err := newFormatter(format, color)
+	if err != nil {
+		log.Fatalln(err)
+	}
 
-		// I like always having a traceid present in the logs.
-		traceID := "00000000-0000-0000-0000-000000000000"
-		if v, ok := m["traceid"]; ok {
-			traceID = fmt.Sprintf("%v", v)
+	// Buffer the output since a line is written for every log.
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	// The report is written once all the input is read.
+	var summary *report
+	if rpt {
+		summary = newReport()
+		defer summary.write(out)
+	}
+
+	// Read the input for log data per line. A reader is used instead of a
+	// scanner since a scanner can't handle lines longer than its buffer.
+	reader := bufio.NewReader(input)
+	for {
+		s, err := reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			log.Println(err)
+			return
 		}
+		if s == "" && err != nil {
+			return
+		}
+		s = strings.TrimRight(s, "\r\n")
 
-		// Build out the know portions of the log in the order
-		// I want them in.
-		b.Reset()
-		b.WriteString(fmt.Sprintf("%s: %s: %s: %s: %s: %s: ",
-			m["service"],
-			m["ts"],
-			m["level"],
-			traceID,
-			m["caller"],
-			m["msg"],
-		))
-
-		// Add the rest of the keys ignoring the ones we already
-		// added for the log.
-		for k, v := range m {
-			switch k {
-			case "service", "ts", "level", "traceid", "caller", "msg":
-				continue
+		// Convert the JSON to a map for processing.
+		m := make(map[string]any)
+		switch err := json.Unmarshal([]byte(s), &m); {
+		case summary != nil:
+			if err == nil && filter.match(m) {
+				summary.add(m)
 			}
 
-			// It's nice to see the key[value] in this format
-			// especially since map ordering is random.
-			b.WriteString(fmt.Sprintf("%s[%v]: ", k, v))
-		}
+		case err != nil:
+			if !filter.active() {
+				fmt.Fprintln(out, s)
+			}
 
-		// Write the new log format, removing the last :
-		out := b.String()
-		fmt.Println(out[:len(out)-2])
-	}
+		case filter.match(m):
+			fmt.Fprintln(out, fmtr.line(s, m))
+		}
 
-	if err := scanner.Err(); err != nil {
-		log.Println(err)
+		// Write the output as soon as the input is caught up so following
+		// a file or a pipe shows the logs as they happen.
+		if reader.Buffered() == 0 {
+			out.Flush()
+		}
 	}
 }
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// Set of messages written by the Logger middleware for every request.
+const (
+	msgStarted   = "request started"
+	msgCompleted = "request completed"
+)
+
+// request represents a request that started but hasn't completed yet.
+type request struct {
+	traceID string
+	method  string
+	path    string
+	ts      string
+}
+
+// pathStats holds the completed requests for a method and path.
+type pathStats struct {
+	method    string
+	path      string
+	errors    int
+	latencies []float64
+}
+
+// report collects the request logs and summarizes them per path. Started
+// requests are kept in order per trace id, method and path, since the node
+// reuses the trace id a client sends and a trace can make the same request
+// more than once.
+type report struct {
+	pending map[string][]request
+	paths   map[string]*pathStats
+}
+
+// newReport constructs an empty report.
+func newReport() *report {
+	return &report{
+		pending: make(map[string][]request),
+		paths:   make(map[string]*pathStats),
+	}
+}
+
+// add records the log line if it was written for a request.
+func (r *report) add(m map[string]any) {
+	traceID, _ := m["traceid"].(string)
+	method, _ := m["method"].(string)
+	path, _ := m["path"].(string)
+
+	key := method + " " + path
+	pendingKey := traceID + " " + key
+
+	switch m["msg"] {
+	case msgStarted:
+		ts, _ := m["ts"].(string)
+		r.pending[pendingKey] = append(r.pending[pendingKey], request{
+			traceID: traceID,
+			method:  method,
+			path:    path,
+			ts:      ts,
+		})
+
+	case msgCompleted:
+
+		// Pair the completed line with the oldest matching started line.
+		if reqs := r.pending[pendingKey]; len(reqs) > 1 {
+			r.pending[pendingKey] = reqs[1:]
+		} else {
+			delete(r.pending, pendingKey)
+		}
+
+		ps, exists := r.paths[key]
+		if !exists {
+			ps = &pathStats{method: method, path: path}
+			r.paths[key] = ps
+		}
+
+		// JSON numbers are decoded as float64.
+		if status, _ := m["statuscode"].(float64); status >= 400 {
+			ps.errors++
+		}
+
+		since, _ := m["since"].(float64)
+		ps.latencies = append(ps.latencies, since)
+	}
+}
+
+// write prints the summary per path and the requests that never completed.
+func (r *report) write(w io.Writer) {
+	keys := make([]string, 0, len(r.paths))
+	for key := range r.paths {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := r.paths[keys[i]], r.paths[keys[j]]
+		if a.path != b.path {
+			return a.path < b.path
+		}
+		return a.method < b.method
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "METHOD\tPATH\tCOUNT\tERRORS\tP50\tP95\tP99")
+	for _, key := range keys {
+		ps := r.paths[key]
+		sort.Float64s(ps.latencies)
+
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%.1f%%\t%s\t%s\t%s\n",
+			ps.method,
+			ps.path,
+			len(ps.latencies),
+			100*float64(ps.errors)/float64(len(ps.latencies)),
+			percentile(ps.latencies, 50),
+			percentile(ps.latencies, 95),
+			percentile(ps.latencies, 99),
+		)
+	}
+	tw.Flush()
+
+	if len(r.pending) == 0 {
+		return
+	}
+
+	var pending []request
+	for _, reqs := range r.pending {
+		pending = append(pending, reqs...)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].ts < pending[j].ts
+	})
+
+	fmt.Fprintf(w, "\nINCOMPLETE REQUESTS (%d)\n", len(pending))
+
+	fmt.Fprintln(tw, "STARTED\tTRACEID\tMETHOD\tPATH")
+	for _, req := range pending {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", req.ts, req.traceID, req.method, req.path)
+	}
+	tw.Flush()
+}
+
+// percentile returns the latency at the percentile using the nearest rank
+// method. The latencies must be sorted.
+func percentile(latencies []float64, p float64) string {
+	if len(latencies) == 0 {
+		return "-"
+	}
+
+	rank := int(math.Ceil(p / 100 * float64(len(latencies))))
+	if rank < 1 {
+		rank = 1
+	}
+
+	d := time.Duration(latencies[rank-1] * float64(time.Second))
+	return d.Round(time.Microsecond).String()
+}
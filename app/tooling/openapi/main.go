@@ -0,0 +1,69 @@
+// This program generates the OpenAPI document for the node's public or
+// private API. It fails if any route is missing documentation.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/ardanlabs/blockchain/app/services/node/handlers"
+	"github.com/ardanlabs/blockchain/foundation/tracer"
+	"github.com/ardanlabs/blockchain/foundation/web"
+	"go.uber.org/zap"
+)
+
+var (
+	mux     string
+	version string
+)
+
+func init() {
+	flag.StringVar(&mux, "mux", "public", "which mux to document: public or private")
+	flag.StringVar(&version, "version", "v1", "version of the API reported in the document")
+}
+
+func main() {
+	flag.Parse()
+
+	if err := run(); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func run() error {
+
+	// The muxes are only constructed to read their routes, so none of the
+	// systems the handlers need are required.
+	cfg := handlers.MuxConfig{
+		Shutdown: make(chan os.Signal, 1),
+		Log:      zap.NewNop().Sugar(),
+		Tracer:   tracer.New("OPENAPI", nil, nil),
+	}
+
+	var app *web.App
+	switch mux {
+	case "public":
+		app = handlers.PublicMux(cfg)
+	case "private":
+		app = handlers.PrivateMux(cfg)
+	default:
+		return fmt.Errorf("unknown mux %q", mux)
+	}
+
+	doc, err := app.OpenAPI("Node "+mux+" API", version)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(doc, "", "    ")
+	if err != nil {
+		return fmt.Errorf("encoding document: %w", err)
+	}
+
+	fmt.Println(string(data))
+
+	return nil
+}
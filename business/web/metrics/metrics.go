@@ -22,6 +22,7 @@ type metrics struct {
 	requests   *expvar.Int
 	errors     *expvar.Int
 	panics     *expvar.Int
+	throttles  *expvar.Int
 }
 
 // init constructs the metrics value that will be used to capture metrics.
@@ -34,6 +35,7 @@ func init() {
 		requests:   expvar.NewInt("requests"),
 		errors:     expvar.NewInt("errors"),
 		panics:     expvar.NewInt("panics"),
+		throttles:  expvar.NewInt("throttles"),
 	}
 }
 
@@ -87,3 +89,10 @@ func AddPanics(ctx context.Context) {
 		v.panics.Add(1)
 	}
 }
+
+// AddThrottles increments the throttled requests metric by 1.
+func AddThrottles(ctx context.Context) {
+	if v, ok := ctx.Value(key).(*metrics); ok {
+		v.throttles.Add(1)
+	}
+}
@@ -0,0 +1,245 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// This holds the single instance of the registry used for the Prometheus
+// exposition. Like the expvar metrics, the registry is a singleton so any
+// part of the codebase can register a gauge.
+var reg = newRegistry()
+
+// buckets are the upper bounds in seconds used for the latency histograms.
+var buckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// validName matches the metric names allowed by Prometheus.
+var validName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// =============================================================================
+
+// routeKey identifies the labels for a request metric.
+type routeKey struct {
+	method string
+	path   string
+	status int
+}
+
+// histogram represents the latency observations for a set of labels.
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// gauge represents a value reported by a subsystem when scraped.
+type gauge struct {
+	name string
+	help string
+	fn   func() float64
+}
+
+// registry holds the request metrics and the registered gauges.
+type registry struct {
+	mu        sync.Mutex
+	requests  map[routeKey]uint64
+	latencies map[routeKey]*histogram
+	gauges    map[string]gauge
+}
+
+// newRegistry constructs an empty registry.
+func newRegistry() *registry {
+	return &registry{
+		requests:  make(map[routeKey]uint64),
+		latencies: make(map[routeKey]*histogram),
+		gauges:    make(map[string]gauge),
+	}
+}
+
+// =============================================================================
+
+// RegisterGauge adds a gauge to the Prometheus exposition. The function is
+// called on every scrape, so subsystems can report values like the block
+// height or the number of peers without touching the middleware.
+func RegisterGauge(name string, help string, fn func() float64) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid metric name %q", name)
+	}
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	if _, exists := reg.gauges[name]; exists {
+		return fmt.Errorf("gauge %q is already registered", name)
+	}
+
+	reg.gauges[name] = gauge{
+		name: name,
+		help: help,
+		fn:   fn,
+	}
+
+	return nil
+}
+
+// ObserveRequest records a completed request for the route.
+func ObserveRequest(method string, path string, status int, latency time.Duration) {
+	key := routeKey{
+		method: method,
+		path:   path,
+		status: status,
+	}
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	reg.requests[key]++
+
+	h, exists := reg.latencies[key]
+	if !exists {
+		h = &histogram{counts: make([]uint64, len(buckets))}
+		reg.latencies[key] = h
+	}
+
+	secs := latency.Seconds()
+	for i, le := range buckets {
+		if secs <= le {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += secs
+}
+
+// Handler returns the handler that writes the metrics in the Prometheus
+// text exposition format.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+		bw := bufio.NewWriter(w)
+		reg.write(bw)
+		bw.Flush()
+	})
+}
+
+// =============================================================================
+
+// routeStats represents a copy of the request metrics for a set of labels.
+type routeStats struct {
+	key      routeKey
+	requests uint64
+	latency  histogram
+}
+
+// snapshot copies the registered gauges and the request metrics so they can
+// be rendered without holding the lock. A slow scraper must never block the
+// requests being observed.
+func (r *registry) snapshot() ([]gauge, []routeStats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	gauges := make([]gauge, 0, len(r.gauges))
+	for _, g := range r.gauges {
+		gauges = append(gauges, g)
+	}
+
+	stats := make([]routeStats, 0, len(r.requests))
+	for key, count := range r.requests {
+		h := r.latencies[key]
+		stats = append(stats, routeStats{
+			key:      key,
+			requests: count,
+			latency: histogram{
+				counts: append([]uint64(nil), h.counts...),
+				count:  h.count,
+				sum:    h.sum,
+			},
+		})
+	}
+
+	return gauges, stats
+}
+
+// write renders every metric in the text exposition format. The gauge
+// functions are called without holding the lock so they can't deadlock with
+// a request being observed.
+func (r *registry) write(w *bufio.Writer) {
+	gauges, stats := r.snapshot()
+
+	sort.Slice(gauges, func(i, j int) bool {
+		return gauges[i].name < gauges[j].name
+	})
+
+	sort.Slice(stats, func(i, j int) bool {
+		a, b := stats[i].key, stats[j].key
+		if a.path != b.path {
+			return a.path < b.path
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.status < b.status
+	})
+
+	writeHeader(w, "go_goroutines", "Number of goroutines that currently exist.", "gauge")
+	fmt.Fprintf(w, "go_goroutines %d\n", runtime.NumGoroutine())
+
+	writeHeader(w, "http_panics_total", "Number of panics recovered while handling requests.", "counter")
+	fmt.Fprintf(w, "http_panics_total %d\n", m.panics.Value())
+
+	writeHeader(w, "http_throttles_total", "Number of requests rejected by the rate limiter.", "counter")
+	fmt.Fprintf(w, "http_throttles_total %d\n", m.throttles.Value())
+
+	for _, g := range gauges {
+		writeHeader(w, g.name, g.help, "gauge")
+		fmt.Fprintf(w, "%s %s\n", g.name, formatFloat(g.fn()))
+	}
+
+	writeHeader(w, "http_requests_total", "Number of requests handled by route and status.", "counter")
+	for _, st := range stats {
+		fmt.Fprintf(w, "http_requests_total{%s} %d\n", st.key.labels(), st.requests)
+	}
+
+	writeHeader(w, "http_request_duration_seconds", "Latency of requests by route and status.", "histogram")
+	for _, st := range stats {
+		h := st.latency
+		labels := st.key.labels()
+
+		for i, le := range buckets {
+			fmt.Fprintf(w, "http_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", labels, formatFloat(le), h.counts[i])
+		}
+		fmt.Fprintf(w, "http_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		fmt.Fprintf(w, "http_request_duration_seconds_sum{%s} %s\n", labels, formatFloat(h.sum))
+		fmt.Fprintf(w, "http_request_duration_seconds_count{%s} %d\n", labels, h.count)
+	}
+}
+
+// labels renders the labels for the route key.
+func (k routeKey) labels() string {
+	return fmt.Sprintf("method=\"%s\",path=\"%s\",status=\"%d\"", escape(k.method), escape(k.path), k.status)
+}
+
+// writeHeader writes the HELP and TYPE lines for a metric.
+func writeHeader(w *bufio.Writer, name string, help string, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, typ)
+}
+
+// escape escapes a label value for the text format.
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// formatFloat renders a float the way Prometheus expects.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
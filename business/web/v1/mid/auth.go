@@ -0,0 +1,91 @@
+package mid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	v1Web "github.com/ardanlabs/blockchain/business/web/v1"
+	"github.com/ardanlabs/blockchain/foundation/blockchain/peer"
+	"github.com/ardanlabs/blockchain/foundation/web"
+)
+
+// ctxNodeKey represents the type of value for the node id context key.
+type ctxNodeKey int
+
+// nodeKey is how the authenticated node id is stored/retrieved.
+const nodeKey ctxNodeKey = 1
+
+// GetNodeID returns the id of the authenticated node that sent the request.
+// An empty string is returned if the request wasn't authenticated.
+func GetNodeID(ctx context.Context) string {
+	v, ok := ctx.Value(nodeKey).(string)
+	if !ok {
+		return ""
+	}
+	return v
+}
+
+// NodeAuth verifies the request was signed by another node. If a list of
+// allowed node ids is provided, the signing node must be in that list. CORS
+// preflight requests can't carry a signature so they are let through when
+// preflight is true, which should only be when CORS is enabled for the mux.
+func NodeAuth(allowed []string, maxSkew time.Duration, preflight bool) web.Middleware {
+
+	// This is the actual middleware function to be executed.
+	m := func(handler web.Handler) web.Handler {
+
+		// Create the handler that will be attached in the middleware chain.
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			if preflight && r.Method == http.MethodOptions {
+				return handler(ctx, w, r)
+			}
+
+			nodeID, err := peer.VerifyRequest(r, maxSkew)
+			if err != nil {
+
+				// A body over the size limit isn't an authentication
+				// failure, so return it for a 413 response.
+				var mbe *http.MaxBytesError
+				if errors.As(err, &mbe) {
+					return err
+				}
+
+				return v1Web.NewRequestError(fmt.Errorf("authenticating node: %w", err), http.StatusUnauthorized)
+			}
+
+			if !isAllowedNode(allowed, nodeID) {
+				return v1Web.NewRequestError(fmt.Errorf("node %s is not allowed", nodeID), http.StatusForbidden)
+			}
+
+			// Add the node id into the context for the rest of the chain.
+			ctx = context.WithValue(ctx, nodeKey, nodeID)
+
+			// Call the next handler.
+			return handler(ctx, w, r)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// isAllowedNode checks the node id against the list of allowed ids. An empty
+// list allows every node.
+func isAllowedNode(allowed []string, nodeID string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	for _, id := range allowed {
+		if strings.EqualFold(id, nodeID) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,193 @@
+package mid_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	v1Web "github.com/ardanlabs/blockchain/business/web/v1"
+	"github.com/ardanlabs/blockchain/business/web/v1/mid"
+	"github.com/ardanlabs/blockchain/foundation/blockchain/peer"
+	"github.com/ardanlabs/blockchain/foundation/tracer"
+	"github.com/ardanlabs/blockchain/foundation/web"
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.uber.org/zap"
+)
+
+// Success and failure markers.
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+func TestNodeAuth(t *testing.T) {
+	allowedKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Should be able to generate a private key : %s", err)
+	}
+
+	otherKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Should be able to generate a private key : %s", err)
+	}
+
+	type table struct {
+		name      string
+		method    string
+		key       *ecdsa.PrivateKey
+		modify    func(r *http.Request)
+		preflight bool
+		status    int
+	}
+
+	tt := []table{
+		{name: "allowed node", method: http.MethodPost, key: allowedKey, status: http.StatusOK},
+		{name: "node not on the allowlist", method: http.MethodPost, key: otherKey, status: http.StatusForbidden},
+		{
+			name:   "tampered body",
+			method: http.MethodPost,
+			key:    allowedKey,
+			modify: func(r *http.Request) {
+				r.Body = io.NopCloser(bytes.NewReader([]byte(`{"value":1000}`)))
+			},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "wrong path",
+			method: http.MethodPost,
+			key:    allowedKey,
+			modify: func(r *http.Request) {
+				r.URL.Path = "/v1/node/other"
+			},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "stale timestamp",
+			method: http.MethodPost,
+			key:    allowedKey,
+			modify: func(r *http.Request) {
+				r.Header.Set(peer.HeaderTimestamp, strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10))
+			},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "unsigned preflight without cors",
+			method: http.MethodOptions,
+			modify: func(r *http.Request) {
+				r.Header.Del(peer.HeaderSignature)
+			},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "unsigned preflight with cors",
+			method: http.MethodOptions,
+			modify: func(r *http.Request) {
+				r.Header.Del(peer.HeaderSignature)
+			},
+			preflight: true,
+			status:    http.StatusOK,
+		},
+	}
+
+	allowed := []string{peer.NodeID(allowedKey)}
+
+	t.Log("Given the need to only accept requests from allowed nodes.")
+	{
+		for testID, tst := range tt {
+			f := func(t *testing.T) {
+				t.Logf("\tTest %d:\tWhen handling a request from a %s.", testID, tst.name)
+				{
+					key := tst.key
+					if key == nil {
+						key = allowedKey
+					}
+
+					r, err := peer.NewRequest(context.Background(), tst.method, "http://localhost:9080/v1/node/sample", []byte(`{"value":10}`), key)
+					if err != nil {
+						t.Fatalf("\t%s\tTest %d:\tShould be able to sign the request : %s.", failed, testID, err)
+					}
+
+					if tst.modify != nil {
+						tst.modify(r)
+					}
+
+					var nodeID string
+					handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+						nodeID = mid.GetNodeID(ctx)
+						return nil
+					}
+
+					h := mid.NodeAuth(allowed, time.Minute, tst.preflight)(handler)
+
+					status := http.StatusOK
+					if err := h(context.Background(), nil, r); err != nil {
+						status = http.StatusInternalServerError
+						if re := v1Web.GetRequestError(err); re != nil {
+							status = re.Status
+						}
+					}
+
+					if status != tst.status {
+						t.Fatalf("\t%s\tTest %d:\tShould get a %d status : got %d.", failed, testID, tst.status, status)
+					}
+					t.Logf("\t%s\tTest %d:\tShould get a %d status.", success, testID, tst.status)
+
+					if tst.status == http.StatusOK && tst.method == http.MethodPost && nodeID != allowed[0] {
+						t.Fatalf("\t%s\tTest %d:\tShould put the node id in the context : got %q.", failed, testID, nodeID)
+					}
+				}
+			}
+
+			t.Run(tst.name, f)
+		}
+	}
+}
+
+func TestNodeAuthBodyLimit(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Should be able to generate a private key : %s", err)
+	}
+
+	log := zap.NewNop().Sugar()
+
+	// The same middleware order as the private mux.
+	app := web.NewApp(make(chan os.Signal, 1), log, tracer.New("TEST", nil, nil),
+		mid.Errors(log),
+		mid.NodeAuth(nil, time.Minute, false),
+	)
+	app.SetMaxBodySize(16)
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+	app.Handle(http.MethodPost, "v1", "/node/sample", handler)
+
+	t.Log("Given the need to limit the size of signed request bodies.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen a node sends a body over the limit.", testID)
+		{
+			r, err := peer.NewRequest(context.Background(), http.MethodPost, "http://localhost:9080/v1/node/sample", []byte(`{"value":"`+strings.Repeat("a", 64)+`"}`), privateKey)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to sign the request : %s.", failed, testID, err)
+			}
+
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, r)
+
+			if w.Code != http.StatusRequestEntityTooLarge {
+				t.Fatalf("\t%s\tTest %d:\tShould get a %d status : got %d %s.", failed, testID, http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+			}
+			t.Logf("\t%s\tTest %d:\tShould get a %d status.", success, testID, http.StatusRequestEntityTooLarge)
+		}
+	}
+}
@@ -3,23 +3,69 @@ package mid
 import (
 	"context"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/ardanlabs/blockchain/foundation/web"
 )
 
-// Cors sets the response headers needed for Cross-Origin Resource Sharing
-func Cors(origin string) web.Middleware {
+// CorsConfig represents the settings for Cross-Origin Resource Sharing. An
+// empty list of allowed origins disables CORS. An origin of "*" allows every
+// origin and an origin like "https://*.example.com" allows its subdomains.
+// Credentials are never allowed for an origin only matched by "*".
+type CorsConfig struct {
+	AllowedOrigins   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// Enabled reports whether any origin is allowed.
+func (cfg CorsConfig) Enabled() bool {
+	return len(cfg.AllowedOrigins) > 0
+}
+
+// Cors sets the response headers needed for Cross-Origin Resource Sharing.
+// Only an origin that matches the allowed origins is echoed back. An origin
+// only matched by "*" gets a literal "*" and no credentials, so not every site
+// can make credentialed requests.
+func Cors(cfg CorsConfig) web.Middleware {
 
 	// This is the actual middleware function to be executed.
 	m := func(handler web.Handler) web.Handler {
 
+		// With no allowed origins there is nothing for the middleware to do.
+		if !cfg.Enabled() {
+			return handler
+		}
+
 		// Create the handler that will be attached in the middleware chain.
 		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 
-			// Set the CORS headers to the response.
-			w.Header().Set("Access-Control-Allow-Origin", origin)
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Origin, Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+			// The response depends on the origin so caches need to know.
+			w.Header().Add("Vary", "Origin")
+
+			origin := r.Header.Get("Origin")
+			if allowOrigin := matchOrigin(cfg.AllowedOrigins, origin); allowOrigin != "" {
+
+				// Set the CORS headers to the response.
+				w.Header().Set("Access-Control-Allow-Origin", allowOrigin)
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, PUT, DELETE, OPTIONS")
+
+				if len(cfg.AllowedHeaders) > 0 {
+					w.Header().Set("Access-Control-Allow-Headers", strings.Join(cfg.AllowedHeaders, ", "))
+				}
+
+				if cfg.AllowCredentials && allowOrigin != "*" {
+					w.Header().Set("Access-Control-Allow-Credentials", "true")
+				}
+
+				if r.Method == http.MethodOptions && cfg.MaxAge > 0 {
+					w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(cfg.MaxAge.Seconds())))
+				}
+			}
 
 			// Call the next handler.
 			return handler(ctx, w, r)
@@ -30,3 +76,46 @@ func Cors(origin string) web.Middleware {
 
 	return m
 }
+
+// matchOrigin checks the origin against the list of allowed origins and
+// returns the value for the Access-Control-Allow-Origin header. The origin is
+// returned if it is listed explicitly or by a subdomain wildcard, "*" if it is
+// only allowed by the "*" entry and an empty string if it isn't allowed.
+func matchOrigin(allowed []string, origin string) string {
+	if origin == "" {
+		return ""
+	}
+
+	var wildcard bool
+	for _, pattern := range allowed {
+		if pattern == "*" {
+			wildcard = true
+			continue
+		}
+
+		if strings.EqualFold(pattern, origin) {
+			return origin
+		}
+
+		// Support a wildcard for the subdomains of an origin.
+		scheme, host, found := strings.Cut(pattern, "://*.")
+		if !found {
+			continue
+		}
+
+		u, err := url.Parse(origin)
+		if err != nil {
+			continue
+		}
+
+		if strings.EqualFold(u.Scheme, scheme) && strings.HasSuffix(strings.ToLower(u.Host), "."+strings.ToLower(host)) {
+			return origin
+		}
+	}
+
+	if wildcard {
+		return "*"
+	}
+
+	return ""
+}
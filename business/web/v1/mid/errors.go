@@ -3,6 +3,7 @@ package mid
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/ardanlabs/blockchain/business/sys/validate"
@@ -36,30 +37,7 @@ func Errors(log *zap.SugaredLogger) web.Middleware {
 				log.Errorw("ERROR", "traceid", v.TraceID, "ERROR", err)
 
 				// Build out the error response.
-				var er v1Web.ErrorResponse
-				var status int
-				switch {
-				case validate.IsFieldErrors(err):
-					fieldErrors := validate.GetFieldErrors(err)
-					er = v1Web.ErrorResponse{
-						Error:  "data validation error",
-						Fields: fieldErrors.Fields(),
-					}
-					status = http.StatusBadRequest
-
-				case v1Web.IsRequestError(err):
-					reqErr := v1Web.GetRequestError(err)
-					er = v1Web.ErrorResponse{
-						Error: reqErr.Error(),
-					}
-					status = reqErr.Status
-
-				default:
-					er = v1Web.ErrorResponse{
-						Error: http.StatusText(http.StatusInternalServerError),
-					}
-					status = http.StatusInternalServerError
-				}
+				er, status := errorResponse(err)
 
 				// Respond with the error back to the client.
 				if err := web.Respond(ctx, w, er, status); err != nil {
@@ -87,3 +65,48 @@ func Errors(log *zap.SugaredLogger) web.Middleware {
 
 	return m
 }
+
+// errorResponse builds the response and status code the client receives
+// for the specified error.
+func errorResponse(err error) (v1Web.ErrorResponse, int) {
+
+	// The body size limit set on the app is checked first, so a middleware
+	// or handler that wraps the error while reading the body can't change
+	// the status the client gets.
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		er := v1Web.ErrorResponse{
+			Error: fmt.Sprintf("body must not be larger than %d bytes", mbe.Limit),
+		}
+		return er, http.StatusRequestEntityTooLarge
+	}
+
+	switch {
+	case validate.IsFieldErrors(err):
+		fieldErrors := validate.GetFieldErrors(err)
+		er := v1Web.ErrorResponse{
+			Error:  "data validation error",
+			Fields: fieldErrors.Fields(),
+		}
+		return er, http.StatusBadRequest
+
+	case v1Web.IsRequestError(err):
+		reqErr := v1Web.GetRequestError(err)
+		er := v1Web.ErrorResponse{
+			Error: reqErr.Error(),
+		}
+		return er, reqErr.Status
+
+	case web.IsDecodeError(err):
+		decErr := web.GetDecodeError(err)
+		er := v1Web.ErrorResponse{
+			Error: decErr.Error(),
+		}
+		return er, decErr.Status
+	}
+
+	er := v1Web.ErrorResponse{
+		Error: http.StatusText(http.StatusInternalServerError),
+	}
+	return er, http.StatusInternalServerError
+}
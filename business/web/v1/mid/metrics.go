@@ -3,6 +3,7 @@ package mid
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/ardanlabs/blockchain/business/web/metrics"
 	"github.com/ardanlabs/blockchain/foundation/web"
@@ -34,6 +35,18 @@ func Metrics() web.Middleware {
 				metrics.AddErrors(ctx)
 			}
 
+			// Record the request against its route pattern, not the raw
+			// path, so path parameters don't create new series. The
+			// response for an error isn't written until the error reaches
+			// the Errors middleware, so use the status it will respond with.
+			if v, verr := web.GetValues(ctx); verr == nil {
+				status := v.StatusCode
+				if err != nil {
+					_, status = errorResponse(err)
+				}
+				metrics.ObserveRequest(r.Method, v.Route, status, time.Since(v.Now))
+			}
+
 			// Return the error so it can be handled further up the chain.
 			return err
 		}
@@ -0,0 +1,78 @@
+package mid
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	v1Web "github.com/ardanlabs/blockchain/business/web/v1"
+	"github.com/ardanlabs/blockchain/foundation/blockchain/peer"
+	"github.com/ardanlabs/blockchain/foundation/web"
+	"go.uber.org/zap"
+)
+
+// PeerScore rejects requests from banned peers and penalizes peers that
+// send requests the node can't accept. Peers are identified by their remote
+// host since a new node id costs nothing to create. It should run before
+// NodeAuth so requests that fail authentication are penalized as well.
+func PeerScore(log *zap.SugaredLogger, scores *peer.Scores) web.Middleware {
+
+	// This is the actual middleware function to be executed.
+	m := func(handler web.Handler) web.Handler {
+
+		// Create the handler that will be attached in the middleware chain.
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			host := remoteHost(r)
+
+			if scores.IsBanned(host) {
+				return v1Web.NewRequestError(errors.New("peer is banned"), http.StatusForbidden)
+			}
+
+			// Call the next handler.
+			err := handler(ctx, w, r)
+
+			if !isPeerFault(err) {
+				if err == nil {
+					scores.Reward(host)
+				}
+				return err
+			}
+
+			banned, serr := scores.Penalize(host, peer.BadRequest)
+			if serr != nil {
+				log.Errorw("peer score", "traceid", web.GetTraceID(ctx), "host", host, "ERROR", serr)
+			}
+			if banned {
+				log.Infow("peer score", "traceid", web.GetTraceID(ctx), "host", host, "status", "peer banned")
+			}
+
+			// Return the error so it can be handled further up the chain.
+			return err
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// isPeerFault reports whether the error was caused by what the peer sent.
+// Any error the client receives with a 4xx status is the peer's fault.
+func isPeerFault(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	_, status := errorResponse(err)
+	return status >= 400 && status < 500
+}
+
+// remoteHost returns the host portion of the remote address for the request.
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
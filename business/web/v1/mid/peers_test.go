@@ -0,0 +1,78 @@
+package mid_test
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	v1Web "github.com/ardanlabs/blockchain/business/web/v1"
+	"github.com/ardanlabs/blockchain/business/web/v1/mid"
+	"github.com/ardanlabs/blockchain/foundation/blockchain/peer"
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.uber.org/zap"
+)
+
+func TestPeerScoreAuthFailures(t *testing.T) {
+	scores, err := peer.NewScores(t.TempDir(), 20, time.Hour)
+	if err != nil {
+		t.Fatalf("Should be able to construct the scores : %s", err)
+	}
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}
+
+	// The same chain as the private mux, with scoring before authentication.
+	h := mid.PeerScore(zap.NewNop().Sugar(), scores)(mid.NodeAuth(nil, time.Minute, false)(handler))
+
+	send := func(stale bool) int {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatalf("Should be able to generate a private key : %s", err)
+		}
+
+		r, err := peer.NewRequest(context.Background(), http.MethodGet, "http://localhost:9080/v1/node/sample", nil, key)
+		if err != nil {
+			t.Fatalf("Should be able to sign the request : %s", err)
+		}
+		r.RemoteAddr = "10.0.0.1:40000"
+
+		if stale {
+			r.Header.Set(peer.HeaderTimestamp, strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10))
+		}
+
+		if err := h(context.Background(), nil, r); err != nil {
+			if re := v1Web.GetRequestError(err); re != nil {
+				return re.Status
+			}
+			return http.StatusInternalServerError
+		}
+		return http.StatusOK
+	}
+
+	t.Log("Given the need to ban peers that fail authentication.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen a host keeps failing authentication with new keys.", testID)
+		{
+			for i := 0; i < 2; i++ {
+				if status := send(true); status != http.StatusUnauthorized {
+					t.Fatalf("\t%s\tTest %d:\tShould reject the stale request : got %d.", failed, testID, status)
+				}
+			}
+			t.Logf("\t%s\tTest %d:\tShould reject the stale requests.", success, testID)
+
+			if !scores.IsBanned("10.0.0.1") {
+				t.Fatalf("\t%s\tTest %d:\tShould ban the host.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould ban the host.", success, testID)
+
+			if status := send(false); status != http.StatusForbidden {
+				t.Fatalf("\t%s\tTest %d:\tShould reject a valid request from a new key on the host : got %d.", failed, testID, status)
+			}
+			t.Logf("\t%s\tTest %d:\tShould reject a valid request from a new key on the host.", success, testID)
+		}
+	}
+}
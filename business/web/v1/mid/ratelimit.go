@@ -0,0 +1,213 @@
+package mid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ardanlabs/blockchain/business/web/metrics"
+	v1Web "github.com/ardanlabs/blockchain/business/web/v1"
+	"github.com/ardanlabs/blockchain/foundation/web"
+)
+
+// RateLimitConfig represents the settings for the rate limit middleware. Rate
+// is the number of requests per second a client can sustain and Burst is the
+// number of requests a client can make at once.
+type RateLimitConfig struct {
+	Rate           float64
+	Burst          int
+	TrustedProxies []*net.IPNet
+}
+
+// ParseTrustedProxies converts a list of IP addresses and CIDR ranges into
+// the networks used to decide if X-Forwarded-For can be trusted.
+func ParseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(proxies))
+
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid proxy address %q", proxy)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy range %q: %w", proxy, err)
+		}
+		nets = append(nets, ipNet)
+	}
+
+	return nets, nil
+}
+
+// RateLimit limits the requests each client can make using a token bucket
+// keyed by the client's IP address. Requests over the limit are rejected
+// with a 429 and a Retry-After header. Each call constructs its own set of
+// buckets so different routes can be given different limits.
+func RateLimit(cfg RateLimitConfig) web.Middleware {
+	lmt := newLimiter(cfg.Rate, cfg.Burst)
+
+	// This is the actual middleware function to be executed.
+	m := func(handler web.Handler) web.Handler {
+
+		// Create the handler that will be attached in the middleware chain.
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			ip := clientIP(r, cfg.TrustedProxies)
+
+			if ok, wait := lmt.allow(ip, time.Now()); !ok {
+				metrics.AddThrottles(ctx)
+
+				secs := int(math.Ceil(wait.Seconds()))
+				w.Header().Set("Retry-After", strconv.Itoa(secs))
+
+				return v1Web.NewRequestError(errors.New("rate limit exceeded"), http.StatusTooManyRequests)
+			}
+
+			// Call the next handler.
+			return handler(ctx, w, r)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// clientIP returns the IP address of the client. The X-Forwarded-For header
+// is only used when the request came from a trusted proxy, and the client is
+// the first address from the right that isn't a trusted proxy.
+func clientIP(r *http.Request, trusted []*net.IPNet) string {
+	ip := remoteHost(r)
+
+	if !isTrustedProxy(trusted, ip) {
+		return ip
+	}
+
+	var hops []string
+	for _, v := range r.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(v, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip = hops[i]
+		if !isTrustedProxy(trusted, ip) {
+			break
+		}
+	}
+
+	return ip
+}
+
+// isTrustedProxy checks if the address belongs to one of the trusted networks.
+func isTrustedProxy(trusted []*net.IPNet, addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+
+	for _, ipNet := range trusted {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// =============================================================================
+
+// bucket represents the tokens available to a client.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// limiter maintains the token buckets for every client.
+type limiter struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     float64
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// newLimiter constructs a limiter for the specified rate and burst.
+func newLimiter(rate float64, burst int) *limiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &limiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// allow takes a token from the client's bucket. If no token is available it
+// returns how long the client needs to wait for the next one.
+func (l *limiter) allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	b, exists := l.buckets[key]
+	if !exists {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	if l.rate <= 0 {
+		return false, time.Minute
+	}
+
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// sweep removes the buckets that have refilled completely since they hold
+// the same state as a new bucket. It runs at most once a minute. The caller
+// must hold the lock.
+func (l *limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package database
 
 import (
 	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
 )
 
 // AccountID represents an account id that is used to sign transactions and is
@@ -9,6 +11,8 @@ import (
 // bytes of the public key.
 type AccountID string
 
+// ToAccountID validates the hex-encoded account and returns it in the
+// checksum form, so the same account always maps to the same key.
 func ToAccountID(hex string) (AccountID, error) {
 
 	a := AccountID(hex)
@@ -17,7 +21,7 @@ func ToAccountID(hex string) (AccountID, error) {
 		return "", errors.New("invalid account format")
 	}
 
-	return a, nil
+	return AccountID(common.HexToAddress(hex).Hex()), nil
 }
 
 // IsAccountID verifies whether the underlying data represents a valid
@@ -35,6 +39,23 @@ func (a AccountID) isAccountID() bool {
 
 // =============================================================================
 
+// Account represents information stored in the database for an individual account.
+type Account struct {
+	AccountID AccountID `json:"account_id"`
+	Nonce     uint64    `json:"nonce"`
+	Balance   uint64    `json:"balance"`
+}
+
+// newAccount constructs a new account value for use.
+func newAccount(accountID AccountID, balance uint64) Account {
+	return Account{
+		AccountID: accountID,
+		Balance:   balance,
+	}
+}
+
+// =============================================================================
+
 // has0xPrefix validates the account starts with a 0x.
 func has0xPrefix(a AccountID) bool {
 	return len(a) >= 2 && a[0] == '0' && (a[1] == 'x' || a[1] == 'X')
@@ -1,3 +1,61 @@
 // Package database handles all the lower level support for maintaining the
 // blockchain in storage and maintaining an in-memory databse of account information.
 package database
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ardanlabs/blockchain/foundation/blockchain/genesis"
+)
+
+// Database manages data related to accounts who have transacted on the blockchain.
+type Database struct {
+	mu       sync.RWMutex
+	genesis  genesis.Genesis
+	accounts map[AccountID]Account
+}
+
+// New constructs a new database and applies account genesis information.
+func New(genesis genesis.Genesis) (*Database, error) {
+	db := Database{
+		genesis:  genesis,
+		accounts: make(map[AccountID]Account),
+	}
+
+	// Update the database with account balance information from genesis.
+	for accountStr, balance := range genesis.Balances {
+		accountID, err := ToAccountID(accountStr)
+		if err != nil {
+			return nil, err
+		}
+		db.accounts[accountID] = newAccount(accountID, balance)
+	}
+
+	return &db, nil
+}
+
+// Query retrieves an account from the database.
+func (db *Database) Query(accountID AccountID) (Account, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	account, exists := db.accounts[accountID]
+	if !exists {
+		return Account{}, errors.New("account does not exist")
+	}
+
+	return account, nil
+}
+
+// Copy makes a copy of the current accounts in the database.
+func (db *Database) Copy() map[AccountID]Account {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	accounts := make(map[AccountID]Account, len(db.accounts))
+	for accountID, account := range db.accounts {
+		accounts[accountID] = account
+	}
+	return accounts
+}
@@ -0,0 +1,170 @@
+package peer
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/ardanlabs/blockchain/foundation/blockchain/signature"
+	"github.com/ardanlabs/blockchain/foundation/tracer"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Set of headers used to authenticate node to node requests.
+const (
+	HeaderTimestamp = "X-Node-Timestamp"
+	HeaderSignature = "X-Node-Signature"
+)
+
+// requestStamp represents the data that is signed for a node to node request.
+// The body is represented by its hash to keep the signed value small.
+type requestStamp struct {
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+	Body      string `json:"body"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// newRequestStamp constructs the value to sign for the specified request data.
+func newRequestStamp(method string, path string, body []byte, timestamp int64) requestStamp {
+	return requestStamp{
+		Method:    method,
+		Path:      path,
+		Body:      hexutil.Encode(crypto.Keccak256(body)),
+		Timestamp: timestamp,
+	}
+}
+
+// =============================================================================
+
+// LoadOrGenerateKey loads the node's private key from the specified path. If
+// the key doesn't exist, a new one is generated and saved at that path.
+func LoadOrGenerateKey(path string) (*ecdsa.PrivateKey, error) {
+	privateKey, err := crypto.LoadECDSA(path)
+	if err == nil {
+		return privateKey, nil
+	}
+
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("loading node key: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("creating node key path: %w", err)
+	}
+
+	privateKey, err = crypto.GenerateKey()
+	if err != nil {
+		return nil, fmt.Errorf("generating node key: %w", err)
+	}
+
+	if err := crypto.SaveECDSA(path, privateKey); err != nil {
+		return nil, fmt.Errorf("saving node key: %w", err)
+	}
+
+	return privateKey, nil
+}
+
+// NodeID returns the id of the node that owns the specified private key. The
+// id is the address derived from the public key.
+func NodeID(privateKey *ecdsa.PrivateKey) string {
+	return crypto.PubkeyToAddress(privateKey.PublicKey).String()
+}
+
+// =============================================================================
+
+// NewRequest constructs a node to node request that is signed with the
+// specified private key. The trace context in ctx is propagated so the
+// peer continues the trace.
+func NewRequest(ctx context.Context, method string, url string, body []byte, privateKey *ecdsa.PrivateKey) (*http.Request, error) {
+	r, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	tracer.Inject(ctx, r.Header)
+
+	if len(body) > 0 {
+		r.Header.Set("Content-Type", "application/json")
+	}
+
+	if err := SignRequest(r, body, privateKey); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// SignRequest signs the method, path, body and current time of the request
+// and sets the signature headers.
+func SignRequest(r *http.Request, body []byte, privateKey *ecdsa.PrivateKey) error {
+	timestamp := time.Now().Unix()
+	stamp := newRequestStamp(r.Method, r.URL.RequestURI(), body, timestamp)
+
+	v, rs, s, err := signature.Sign(stamp, privateKey)
+	if err != nil {
+		return fmt.Errorf("signing request: %w", err)
+	}
+
+	r.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	r.Header.Set(HeaderSignature, signature.SignatureString(v, rs, s))
+
+	return nil
+}
+
+// VerifyRequest checks the signature headers of a node to node request
+// against the request data and returns the id of the node that signed it.
+// Requests signed outside the allowed clock skew are rejected.
+func VerifyRequest(r *http.Request, maxSkew time.Duration) (string, error) {
+	sigStr := r.Header.Get(HeaderSignature)
+	if sigStr == "" {
+		return "", errors.New("missing request signature")
+	}
+
+	timestamp, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		return "", errors.New("invalid request timestamp")
+	}
+
+	skew := time.Since(time.Unix(timestamp, 0))
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > maxSkew {
+		return "", errors.New("request timestamp outside allowed skew")
+	}
+
+	// Read the body so it can be verified, then put it back for the handlers.
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return "", fmt.Errorf("reading body: %w", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	v, rs, s, err := signature.ToVRSFromHexSignature(sigStr)
+	if err != nil {
+		return "", fmt.Errorf("decoding signature: %w", err)
+	}
+
+	if err := signature.VerifySignature(v, rs, s); err != nil {
+		return "", err
+	}
+
+	stamp := newRequestStamp(r.Method, r.URL.RequestURI(), body, timestamp)
+
+	nodeID, err := signature.FromAddress(stamp, v, rs, s)
+	if err != nil {
+		return "", fmt.Errorf("recovering node id: %w", err)
+	}
+
+	return nodeID, nil
+}
@@ -0,0 +1,140 @@
+package peer_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ardanlabs/blockchain/foundation/blockchain/peer"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Success and failure markers.
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+func TestVerifyRequest(t *testing.T) {
+	type table struct {
+		name   string
+		modify func(r *http.Request)
+		signer bool
+		err    bool
+	}
+
+	tt := []table{
+		{
+			name:   "valid request",
+			modify: func(r *http.Request) {},
+			signer: true,
+		},
+		{
+			name: "tampered body",
+			modify: func(r *http.Request) {
+				r.Body = io.NopCloser(bytes.NewReader([]byte(`{"value":1000}`)))
+			},
+		},
+		{
+			name: "wrong path",
+			modify: func(r *http.Request) {
+				r.URL.Path = "/v1/node/other"
+			},
+		},
+		{
+			name: "wrong method",
+			modify: func(r *http.Request) {
+				r.Method = http.MethodPut
+			},
+		},
+		{
+			name: "stale timestamp",
+			modify: func(r *http.Request) {
+				r.Header.Set(peer.HeaderTimestamp, strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10))
+			},
+			err: true,
+		},
+		{
+			name: "future timestamp",
+			modify: func(r *http.Request) {
+				r.Header.Set(peer.HeaderTimestamp, strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+			},
+			err: true,
+		},
+		{
+			name: "missing signature",
+			modify: func(r *http.Request) {
+				r.Header.Del(peer.HeaderSignature)
+			},
+			err: true,
+		},
+		{
+			name: "malformed signature",
+			modify: func(r *http.Request) {
+				r.Header.Set(peer.HeaderSignature, "0x1234")
+			},
+			err: true,
+		},
+	}
+
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Should be able to generate a private key : %s", err)
+	}
+	nodeID := peer.NodeID(privateKey)
+
+	t.Log("Given the need to authenticate node to node requests.")
+	{
+		for testID, tst := range tt {
+			f := func(t *testing.T) {
+				t.Logf("\tTest %d:\tWhen verifying a request with a %s.", testID, tst.name)
+				{
+					body := []byte(`{"value":10}`)
+
+					r, err := peer.NewRequest(context.Background(), http.MethodPost, "http://localhost:9080/v1/node/sample?x=1", body, privateKey)
+					if err != nil {
+						t.Fatalf("\t%s\tTest %d:\tShould be able to sign the request : %s.", failed, testID, err)
+					}
+					t.Logf("\t%s\tTest %d:\tShould be able to sign the request.", success, testID)
+
+					tst.modify(r)
+
+					gotID, err := peer.VerifyRequest(r, time.Minute)
+					if tst.err {
+						if err == nil {
+							t.Fatalf("\t%s\tTest %d:\tShould reject the request.", failed, testID)
+						}
+						t.Logf("\t%s\tTest %d:\tShould reject the request : %s.", success, testID, err)
+						return
+					}
+
+					// A signature over different data still recovers a node
+					// id, just not the id of the node that signed it.
+					if err != nil {
+						t.Fatalf("\t%s\tTest %d:\tShould be able to recover a node id : %s.", failed, testID, err)
+					}
+					t.Logf("\t%s\tTest %d:\tShould be able to recover a node id.", success, testID)
+
+					if (gotID == nodeID) != tst.signer {
+						t.Fatalf("\t%s\tTest %d:\tShould recover the signing node %t : got %s, signer %s.", failed, testID, tst.signer, gotID, nodeID)
+					}
+					t.Logf("\t%s\tTest %d:\tShould recover the signing node %t.", success, testID, tst.signer)
+
+					if tst.signer {
+						data, err := io.ReadAll(r.Body)
+						if err != nil || !bytes.Equal(data, body) {
+							t.Fatalf("\t%s\tTest %d:\tShould leave the body for the handlers : got %q, %v.", failed, testID, data, err)
+						}
+						t.Logf("\t%s\tTest %d:\tShould leave the body for the handlers.", success, testID)
+					}
+				}
+			}
+
+			t.Run(tst.name, f)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+// Package peer maintains the peer related information such as the scores
+// and bans for the nodes this node is talking to, and the authentication of
+// node to node requests.
+package peer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Offense represents a protocol violation or failure committed by a peer.
+type Offense int
+
+// Set of offenses a peer can be penalized for.
+const (
+	BadRequest Offense = iota + 1
+)
+
+// penalties maps an offense to the number of points taken off a peer's score.
+var penalties = map[Offense]int{
+	BadRequest: 10,
+}
+
+// String implements the fmt.Stringer interface.
+func (o Offense) String() string {
+	switch o {
+	case BadRequest:
+		return "bad request"
+	}
+	return fmt.Sprintf("offense(%d)", int(o))
+}
+
+// =============================================================================
+
+// Status represents the current score and ban information for a peer.
+type Status struct {
+	Host        string    `json:"host"`
+	Score       int       `json:"score"`
+	BannedUntil time.Time `json:"banned_until"`
+}
+
+// IsBanned reports whether the peer is banned at the specified time.
+func (s Status) IsBanned(now time.Time) bool {
+	return now.Before(s.BannedUntil)
+}
+
+// =============================================================================
+
+// Scores tracks the score for each peer. A peer starts with a score of zero
+// and is banned for a period of time once its score drops to the negative of
+// the ban score. Bans are persisted to disk so they survive a restart.
+type Scores struct {
+	mu          sync.Mutex
+	path        string
+	banScore    int
+	banDuration time.Duration
+	peers       map[string]*Status
+}
+
+// NewScores constructs a Scores value and loads any bans that were persisted
+// in the specified database path.
+func NewScores(dbPath string, banScore int, banDuration time.Duration) (*Scores, error) {
+	if banScore <= 0 {
+		return nil, errors.New("ban score must be greater than zero")
+	}
+
+	if err := os.MkdirAll(dbPath, 0755); err != nil {
+		return nil, fmt.Errorf("creating db path: %w", err)
+	}
+
+	s := Scores{
+		path:        filepath.Join(dbPath, "bans.json"),
+		banScore:    banScore,
+		banDuration: banDuration,
+		peers:       make(map[string]*Status),
+	}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// Penalize reduces the score of the specified peer based on the offense. If
+// the score drops far enough the peer is banned and true is returned.
+func (s *Scores) Penalize(host string, offense Offense) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.status(host)
+	st.Score -= penalties[offense]
+
+	if st.Score > -s.banScore {
+		return false, nil
+	}
+
+	// The score is reset so the peer starts over once the ban expires.
+	st.Score = 0
+	st.BannedUntil = time.Now().UTC().Add(s.banDuration)
+
+	return true, s.save()
+}
+
+// Reward adds a point back to the score of a peer that behaved, never
+// taking the score above zero. A peer that is back to zero and not banned
+// is forgotten, so peers that recover don't stay in memory.
+func (s *Scores) Reward(host string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, exists := s.peers[host]
+	if !exists {
+		return
+	}
+
+	if st.Score < 0 {
+		st.Score++
+	}
+
+	if st.Score == 0 && !st.IsBanned(time.Now()) {
+		delete(s.peers, host)
+	}
+}
+
+// IsBanned reports whether the specified peer is currently banned.
+func (s *Scores) IsBanned(host string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, exists := s.peers[host]
+	if !exists {
+		return false
+	}
+
+	return st.IsBanned(time.Now())
+}
+
+// Unban removes the ban and resets the score for the specified peer.
+func (s *Scores) Unban(host string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.peers[host]; !exists {
+		return fmt.Errorf("peer %q is not known", host)
+	}
+
+	delete(s.peers, host)
+
+	return s.save()
+}
+
+// List returns a copy of the status for every known peer sorted by host.
+func (s *Scores) List() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]Status, 0, len(s.peers))
+	for _, st := range s.peers {
+		list = append(list, *st)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Host < list[j].Host
+	})
+
+	return list
+}
+
+// =============================================================================
+
+// status returns the status for the specified peer, adding the peer if it
+// doesn't exist. The caller must hold the lock.
+func (s *Scores) status(host string) *Status {
+	st, exists := s.peers[host]
+	if !exists {
+		st = &Status{Host: host}
+		s.peers[host] = st
+	}
+	return st
+}
+
+// load reads the persisted bans from disk, ignoring the ones that expired.
+func (s *Scores) load() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("reading bans: %w", err)
+	}
+
+	var bans []Status
+	if err := json.Unmarshal(data, &bans); err != nil {
+		return fmt.Errorf("decoding bans: %w", err)
+	}
+
+	now := time.Now()
+	for _, ban := range bans {
+		if ban.IsBanned(now) {
+			st := ban
+			s.peers[st.Host] = &st
+		}
+	}
+
+	return nil
+}
+
+// save writes the active bans to disk. The caller must hold the lock.
+func (s *Scores) save() error {
+	now := time.Now()
+
+	bans := []Status{}
+	for _, st := range s.peers {
+		if st.IsBanned(now) {
+			bans = append(bans, *st)
+		}
+	}
+
+	data, err := json.MarshalIndent(bans, "", "    ")
+	if err != nil {
+		return fmt.Errorf("encoding bans: %w", err)
+	}
+
+	// Write to a temp file first so a crash can't leave a partial file.
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("writing bans: %w", err)
+	}
+
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("replacing bans: %w", err)
+	}
+
+	return nil
+}
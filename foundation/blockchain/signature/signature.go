@@ -109,6 +109,25 @@ func ToSignatureBytes(v, r, s *big.Int) []byte {
 	return sig
 }
 
+// ToVRSFromHexSignature converts a hex representation of the signature into
+// its R, S and V parts. The signature is expected to include the jessercID.
+func ToVRSFromHexSignature(sigStr string) (v, r, s *big.Int, err error) {
+	sig, err := hexutil.Decode(sigStr)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if len(sig) != crypto.SignatureLength {
+		return nil, nil, nil, errors.New("invalid signature length")
+	}
+
+	r = big.NewInt(0).SetBytes(sig[:32])
+	s = big.NewInt(0).SetBytes(sig[32:64])
+	v = big.NewInt(0).SetBytes([]byte{sig[64]})
+
+	return v, r, s, nil
+}
+
 // =============================================================================
 
 // SignatureString returns the signature as a string.
@@ -0,0 +1,476 @@
+// Package cbor provides a minimal encoder for the Concise Binary Object
+// Representation. Values are encoded with the same field names and
+// representations as encoding/json, so a client gets the same document in
+// either format. Integers too large for 64 bits, like big.Int values, are
+// encoded as bignums so no precision is lost.
+// https://www.rfc-editor.org/rfc/rfc8949
+package cbor
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Set of major types used in the initial byte of an item.
+const (
+	majorUint   = 0
+	majorNegInt = 1
+	majorBytes  = 2
+	majorText   = 3
+	majorArray  = 4
+	majorMap    = 5
+	majorTag    = 6
+)
+
+// Set of tags for integers that don't fit in 64 bits.
+const (
+	tagPosBignum = 2
+	tagNegBignum = 3
+)
+
+// Set of simple values and the float64 marker.
+const (
+	simpleFalse = 0xf4
+	simpleTrue  = 0xf5
+	simpleNull  = 0xf6
+	float64Item = 0xfb
+)
+
+// Set of types with custom behavior.
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	bigIntType        = reflect.TypeOf((*big.Int)(nil))
+)
+
+// Marshal returns the CBOR encoding of the value.
+func Marshal(v any) ([]byte, error) {
+	var e encoder
+	if err := e.encode(reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+// =============================================================================
+
+// encoder writes the items into a buffer.
+type encoder struct {
+	buf bytes.Buffer
+}
+
+// encode writes the item for the value.
+func (e *encoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		e.buf.WriteByte(simpleNull)
+		return nil
+	}
+
+	// Types with their own JSON or text representation are encoded using
+	// that representation, like time.Time and big.Int. Like encoding/json,
+	// methods on the pointer are used when the value is addressable.
+	if v.Kind() != reflect.Pointer && v.CanAddr() && reflect.PointerTo(v.Type()).Implements(jsonMarshalerType) {
+		v = v.Addr()
+	}
+
+	// Big integers are encoded directly since the round trip through JSON
+	// is slow.
+	if v.Type() == bigIntType {
+		if v.IsNil() {
+			e.buf.WriteByte(simpleNull)
+			return nil
+		}
+		e.bigInt(v.Interface().(*big.Int))
+		return nil
+	}
+
+	if v.Type().Implements(jsonMarshalerType) {
+		if isNil(v) {
+			e.buf.WriteByte(simpleNull)
+			return nil
+		}
+		return e.encodeJSON(v.Interface().(json.Marshaler))
+	}
+
+	if v.Type().Implements(textMarshalerType) {
+		if isNil(v) {
+			e.buf.WriteByte(simpleNull)
+			return nil
+		}
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return err
+		}
+		e.head(majorText, uint64(len(text)))
+		e.buf.Write(text)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			e.buf.WriteByte(simpleNull)
+			return nil
+		}
+		return e.encode(v.Elem())
+
+	case reflect.Bool:
+		if v.Bool() {
+			e.buf.WriteByte(simpleTrue)
+		} else {
+			e.buf.WriteByte(simpleFalse)
+		}
+		return nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.int(v.Int())
+		return nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.head(majorUint, v.Uint())
+		return nil
+
+	case reflect.Float32, reflect.Float64:
+		e.float(v.Float())
+		return nil
+
+	case reflect.String:
+		e.head(majorText, uint64(v.Len()))
+		e.buf.WriteString(v.String())
+		return nil
+
+	case reflect.Slice:
+		if v.IsNil() {
+			e.buf.WriteByte(simpleNull)
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.head(majorBytes, uint64(v.Len()))
+			e.buf.Write(v.Bytes())
+			return nil
+		}
+		return e.array(v)
+
+	case reflect.Array:
+		return e.array(v)
+
+	case reflect.Map:
+		if v.IsNil() {
+			e.buf.WriteByte(simpleNull)
+			return nil
+		}
+		return e.encodeMap(v)
+
+	case reflect.Struct:
+		return e.encodeStruct(v)
+	}
+
+	return fmt.Errorf("cbor: unsupported type %s", v.Type())
+}
+
+// encodeJSON writes the item for the JSON representation of the value.
+func (e *encoder) encodeJSON(m json.Marshaler) error {
+	data, err := m.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var val any
+	if err := decoder.Decode(&val); err != nil {
+		return err
+	}
+
+	return e.encodeAny(val)
+}
+
+// encodeAny writes the item for a value decoded from JSON.
+func (e *encoder) encodeAny(val any) error {
+	switch val := val.(type) {
+	case json.Number:
+		if n, err := val.Int64(); err == nil {
+			e.int(n)
+			return nil
+		}
+
+		// An integer too large for an int64 must not lose precision by
+		// being encoded as a float.
+		if b, ok := new(big.Int).SetString(val.String(), 10); ok {
+			e.bigInt(b)
+			return nil
+		}
+
+		f, err := val.Float64()
+		if err != nil {
+			return err
+		}
+		e.float(f)
+		return nil
+
+	case []any:
+		e.head(majorArray, uint64(len(val)))
+		for _, item := range val {
+			if err := e.encodeAny(item); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		e.head(majorMap, uint64(len(val)))
+		for _, k := range keys {
+			e.head(majorText, uint64(len(k)))
+			e.buf.WriteString(k)
+			if err := e.encodeAny(val[k]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return e.encode(reflect.ValueOf(val))
+}
+
+// array writes the items of a slice or array.
+func (e *encoder) array(v reflect.Value) error {
+	e.head(majorArray, uint64(v.Len()))
+	for i := 0; i < v.Len(); i++ {
+		if err := e.encode(v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeMap writes the pairs of a map sorted by key so the output is
+// deterministic.
+func (e *encoder) encodeMap(v reflect.Value) error {
+	keys := v.MapKeys()
+
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		name, err := mapKey(k)
+		if err != nil {
+			return err
+		}
+		names[i] = name
+	}
+
+	order := make([]int, len(keys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return names[order[i]] < names[order[j]]
+	})
+
+	e.head(majorMap, uint64(len(keys)))
+	for _, i := range order {
+		e.head(majorText, uint64(len(names[i])))
+		e.buf.WriteString(names[i])
+		if err := e.encode(v.MapIndex(keys[i])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mapKey returns the key as a string the same way encoding/json does.
+func mapKey(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		text, err := tm.MarshalText()
+		return string(text), err
+	}
+
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fmt.Sprintf("%d", k.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return fmt.Sprintf("%d", k.Uint()), nil
+	}
+
+	return "", fmt.Errorf("cbor: unsupported map key type %s", k.Type())
+}
+
+// field represents a struct field that is encoded.
+type field struct {
+	name  string
+	value reflect.Value
+}
+
+// encodeStruct writes the struct as a map using the JSON field names.
+func (e *encoder) encodeStruct(v reflect.Value) error {
+	var fields []field
+	structFields(v, &fields)
+
+	e.head(majorMap, uint64(len(fields)))
+	for _, f := range fields {
+		e.head(majorText, uint64(len(f.name)))
+		e.buf.WriteString(f.name)
+		if err := e.encode(f.value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// structFields collects the fields of the struct that are encoded, honoring
+// the json tags and flattening embedded structs the way encoding/json does.
+func structFields(v reflect.Value, fields *[]field) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		fld := t.Field(i)
+
+		tag := fld.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		fv := v.Field(i)
+
+		if fld.Anonymous && name == "" {
+			ft := fld.Type
+			if ft.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				ft = ft.Elem()
+				fv = fv.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				structFields(fv, fields)
+				continue
+			}
+		}
+
+		if !fld.IsExported() {
+			continue
+		}
+
+		if strings.Contains(opts, "omitempty") && isEmpty(fv) {
+			continue
+		}
+
+		if name == "" {
+			name = fld.Name
+		}
+
+		*fields = append(*fields, field{name: name, value: fv})
+	}
+}
+
+// =============================================================================
+
+// head writes the initial byte of an item and its argument.
+func (e *encoder) head(major byte, n uint64) {
+	m := major << 5
+
+	switch {
+	case n < 24:
+		e.buf.WriteByte(m | byte(n))
+	case n <= math.MaxUint8:
+		e.buf.WriteByte(m | 24)
+		e.buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		e.buf.WriteByte(m | 25)
+		e.buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	case n <= math.MaxUint32:
+		e.buf.WriteByte(m | 26)
+		e.buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	default:
+		e.buf.WriteByte(m | 27)
+		e.buf.Write(binary.BigEndian.AppendUint64(nil, n))
+	}
+}
+
+// int writes a signed integer.
+func (e *encoder) int(n int64) {
+	if n >= 0 {
+		e.head(majorUint, uint64(n))
+		return
+	}
+	e.head(majorNegInt, uint64(-1-n))
+}
+
+// bigInt writes an integer of any size. Integers that don't fit in 64 bits
+// are written as a tagged bignum.
+func (e *encoder) bigInt(b *big.Int) {
+	major, tag := byte(majorUint), uint64(tagPosBignum)
+
+	// Negative integers are encoded as -1 - n.
+	n := b
+	if b.Sign() < 0 {
+		major, tag = majorNegInt, tagNegBignum
+		n = new(big.Int).Neg(b)
+		n.Sub(n, big.NewInt(1))
+	}
+
+	if n.IsUint64() {
+		e.head(major, n.Uint64())
+		return
+	}
+
+	data := n.Bytes()
+	e.head(majorTag, tag)
+	e.head(majorBytes, uint64(len(data)))
+	e.buf.Write(data)
+}
+
+// float writes a float as a 64 bit float.
+func (e *encoder) float(f float64) {
+	e.buf.WriteByte(float64Item)
+	e.buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(f)))
+}
+
+// isNil reports whether the value is a nil pointer or interface.
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
+
+// isEmpty reports whether the value is empty as defined by the omitempty
+// option of encoding/json.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
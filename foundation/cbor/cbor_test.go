@@ -0,0 +1,124 @@
+package cbor_test
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ardanlabs/blockchain/foundation/cbor"
+)
+
+// Success and failure markers.
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+type inner struct {
+	X int `json:"x"`
+}
+
+type outer struct {
+	inner
+	Y int `json:"y"`
+}
+
+type tagged struct {
+	A int    `json:"a"`
+	B string `json:"b,omitempty"`
+	C bool   `json:"-"`
+	d int
+}
+
+func TestMarshal(t *testing.T) {
+	type table struct {
+		name  string
+		value any
+		exp   string
+	}
+
+	// The vectors up to the map, and the bignums, come from Appendix A of
+	// RFC 8949.
+	tt := []table{
+		{name: "zero", value: 0, exp: "00"},
+		{name: "23", value: 23, exp: "17"},
+		{name: "24", value: 24, exp: "1818"},
+		{name: "100", value: 100, exp: "1864"},
+		{name: "1000", value: 1000, exp: "1903e8"},
+		{name: "1000000", value: 1000000, exp: "1a000f4240"},
+		{name: "1000000000000", value: 1000000000000, exp: "1b000000e8d4a51000"},
+		{name: "max uint64", value: uint64(math.MaxUint64), exp: "1bffffffffffffffff"},
+		{name: "-1", value: -1, exp: "20"},
+		{name: "-100", value: -100, exp: "3863"},
+		{name: "-1000", value: -1000, exp: "3903e7"},
+		{name: "min int64", value: int64(math.MinInt64), exp: "3b7fffffffffffffff"},
+		{name: "1.1", value: 1.1, exp: "fb3ff199999999999a"},
+		{name: "false", value: false, exp: "f4"},
+		{name: "true", value: true, exp: "f5"},
+		{name: "nil", value: nil, exp: "f6"},
+		{name: "empty string", value: "", exp: "60"},
+		{name: "string", value: "IETF", exp: "6449455446"},
+		{name: "bytes", value: []byte{1, 2, 3, 4}, exp: "4401020304"},
+		{name: "array", value: []int{1, 2, 3}, exp: "83010203"},
+		{name: "map", value: map[string]any{"b": []int{2, 3}, "a": 1}, exp: "a26161016162820203"},
+		{name: "nil slice", value: []int(nil), exp: "f6"},
+		{name: "nil map", value: map[string]int(nil), exp: "f6"},
+		{name: "nil pointer", value: (*int)(nil), exp: "f6"},
+		{name: "int keys", value: map[int]bool{10: true, 2: false}, exp: "a2623130f56132f4"},
+		{name: "struct tags", value: tagged{A: 1, C: true, d: 2}, exp: "a1616101"},
+		{name: "embedded struct", value: outer{inner: inner{X: 1}, Y: 2}, exp: "a2617801617902"},
+		{name: "big int", value: big.NewInt(70000), exp: "1a00011170"},
+		{name: "256 bit big int", value: new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1)), exp: "c25820" + strings.Repeat("ff", 32)},
+		{name: "big int 2^64", value: new(big.Int).Lsh(big.NewInt(1), 64), exp: "c249010000000000000000"},
+		{name: "big int -2^64", value: new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), 64)), exp: "3bffffffffffffffff"},
+		{name: "big int -2^64-1", value: new(big.Int).Sub(new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), 64)), big.NewInt(1)), exp: "c349010000000000000000"},
+		{name: "nil big int", value: (*big.Int)(nil), exp: "f6"},
+		{name: "json 2^63", value: json.RawMessage("9223372036854775808"), exp: "1b8000000000000000"},
+		{name: "json 2^64", value: json.RawMessage("18446744073709551616"), exp: "c249010000000000000000"},
+		{name: "json -2^64-1", value: json.RawMessage("-18446744073709551617"), exp: "c349010000000000000000"},
+		{name: "json float", value: json.RawMessage("1.5"), exp: "fb3ff8000000000000"},
+		{name: "time", value: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), exp: "74323032362d30312d30325430333a30343a30355a"},
+	}
+
+	t.Log("Given the need to encode values as CBOR.")
+	{
+		for testID, tst := range tt {
+			f := func(t *testing.T) {
+				t.Logf("\tTest %d:\tWhen encoding %s.", testID, tst.name)
+				{
+					data, err := cbor.Marshal(tst.value)
+					if err != nil {
+						t.Fatalf("\t%s\tTest %d:\tShould be able to encode the value : %s.", failed, testID, err)
+					}
+					t.Logf("\t%s\tTest %d:\tShould be able to encode the value.", success, testID)
+
+					got := hex.EncodeToString(data)
+					if got != tst.exp {
+						t.Fatalf("\t%s\tTest %d:\tShould get the bytes %s : got %s.", failed, testID, tst.exp, got)
+					}
+					t.Logf("\t%s\tTest %d:\tShould get the bytes %s.", success, testID, tst.exp)
+				}
+			}
+
+			t.Run(tst.name, f)
+		}
+	}
+}
+
+func TestMarshalUnsupported(t *testing.T) {
+	t.Log("Given the need to reject values CBOR can't represent.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen encoding a channel.", testID)
+		{
+			if _, err := cbor.Marshal(make(chan int)); err == nil {
+				t.Fatalf("\t%s\tTest %d:\tShould get an error.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get an error.", success, testID)
+		}
+	}
+}
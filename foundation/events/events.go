@@ -0,0 +1,182 @@
+// Package events allows for the registering and receiving of events that
+// occur inside the node, like blocks being mined or transactions accepted.
+package events
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Set of event types the node publishes.
+const (
+	TypeBlockMined    = "block_mined"
+	TypeBlockReceived = "block_received"
+	TypeTxAccepted    = "tx_accepted"
+	TypeTxRejected    = "tx_rejected"
+	TypeReorg         = "reorg"
+)
+
+// ErrLagged is reported by a subscription that was dropped because the
+// subscriber couldn't keep up with the events being sent.
+var ErrLagged = errors.New("subscriber dropped for falling behind")
+
+// Event represents something that happened inside the node. Accounts lists
+// the accounts touched by the event so subscribers can filter on them.
+type Event struct {
+	Type     string    `json:"type"`
+	Time     time.Time `json:"time"`
+	Accounts []string  `json:"accounts,omitempty"`
+	Data     any       `json:"data,omitempty"`
+}
+
+// =============================================================================
+
+// Filter restricts the events a subscriber receives. An empty filter
+// matches every event.
+type Filter struct {
+	Types   []string
+	Account string
+}
+
+// Match reports whether the event passes the filter.
+func (f Filter) Match(evt Event) bool {
+	if len(f.Types) > 0 && !contains(f.Types, evt.Type) {
+		return false
+	}
+
+	if f.Account != "" && !contains(evt.Accounts, f.Account) {
+		return false
+	}
+
+	return true
+}
+
+// contains performs a case-insensitive search for the value.
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// =============================================================================
+
+// Subscription represents a subscriber's registration for events. Events are
+// received on C, which is closed when the subscription ends.
+type Subscription struct {
+	C <-chan Event
+
+	id     uint64
+	filter Filter
+	ch     chan Event
+	err    error
+}
+
+// Err returns the reason the subscription ended, if it was ended by the
+// events value.
+func (s *Subscription) Err() error {
+	return s.err
+}
+
+// =============================================================================
+
+// Events maintains the set of subscribers and delivers events to them.
+// Sending never blocks: a subscriber whose buffer is full is dropped so a
+// slow client can't stall the node.
+type Events struct {
+	mu   sync.Mutex
+	subs map[uint64]*Subscription
+	next uint64
+}
+
+// New constructs an events value for registering and receiving events.
+func New() *Events {
+	return &Events{
+		subs: make(map[uint64]*Subscription),
+	}
+}
+
+// Subscribe registers a new subscriber for the events that match the filter.
+// The buffer is the number of events that can queue up for the subscriber
+// before it's considered too slow and dropped.
+func (evts *Events) Subscribe(filter Filter, buffer int) *Subscription {
+	evts.mu.Lock()
+	defer evts.mu.Unlock()
+
+	evts.next++
+
+	ch := make(chan Event, buffer)
+	sub := Subscription{
+		C:      ch,
+		id:     evts.next,
+		filter: filter,
+		ch:     ch,
+	}
+	evts.subs[sub.id] = &sub
+
+	return &sub
+}
+
+// Unsubscribe removes the subscriber and closes its channel.
+func (evts *Events) Unsubscribe(sub *Subscription) {
+	evts.mu.Lock()
+	defer evts.mu.Unlock()
+
+	evts.remove(sub, nil)
+}
+
+// Send delivers the event to every subscriber with a matching filter.
+func (evts *Events) Send(evt Event) {
+	if evt.Time.IsZero() {
+		evt.Time = time.Now().UTC()
+	}
+
+	evts.mu.Lock()
+	defer evts.mu.Unlock()
+
+	for _, sub := range evts.subs {
+		if !sub.filter.Match(evt) {
+			continue
+		}
+
+		select {
+		case sub.ch <- evt:
+		default:
+			evts.remove(sub, ErrLagged)
+		}
+	}
+}
+
+// Count returns the number of active subscribers.
+func (evts *Events) Count() int {
+	evts.mu.Lock()
+	defer evts.mu.Unlock()
+
+	return len(evts.subs)
+}
+
+// Shutdown closes every subscription so subscribers can return.
+func (evts *Events) Shutdown() {
+	evts.mu.Lock()
+	defer evts.mu.Unlock()
+
+	for _, sub := range evts.subs {
+		evts.remove(sub, nil)
+	}
+}
+
+// remove deletes the subscriber and closes its channel. The caller must
+// hold the lock.
+func (evts *Events) remove(sub *Subscription, err error) {
+	if _, exists := evts.subs[sub.id]; !exists {
+		return
+	}
+
+	delete(evts.subs, sub.id)
+	sub.err = err
+	close(sub.ch)
+}
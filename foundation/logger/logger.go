@@ -3,25 +3,185 @@
 package logger
 
 import (
+	"fmt"
+	"sync"
+	"time"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-// New constructs a Sugared Logger that writes to stdout and
-// provides human-readable timestamps.
-func New(service string) (*zap.SugaredLogger, error) {
-	config := zap.NewProductionConfig()
-	config.OutputPaths = []string{"stdout"}
-	config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-	config.DisableStacktrace = true
-	config.InitialFields = map[string]any{
-		"service": service,
+// RootName is the name used for the level of the root logger.
+const RootName = "root"
+
+// Config provides the settings for the logger.
+type Config struct {
+
+	// Outputs are the paths the logs are written to. Any path zap accepts
+	// can be used, including a RotateURL for rotating files. Stdout is used
+	// when empty.
+	Outputs []string
+
+	// Level is the level of the root logger. Info is used when empty.
+	Level string
+
+	// Levels sets the level for the named subsystem loggers. A subsystem
+	// without a level starts at the level of the root logger.
+	Levels map[string]string
+
+	// Logs with the same level and message are sampled once more than
+	// SamplingInitial of them are written in a second. After that only
+	// every SamplingThereafter log is written. A zero value for either
+	// disables sampling.
+	SamplingInitial    int
+	SamplingThereafter int
+}
+
+// Logger provides a Sugared Logger and the named loggers for the
+// subsystems of the application. Every logger has its own level, which
+// can be changed while the application is running, and they all write to
+// the same output.
+type Logger struct {
+	*zap.SugaredLogger
+	cfg     Config
+	encoder zapcore.Encoder
+	sink    zapcore.WriteSyncer
+	close   func()
+	opts    []zap.Option
+
+	mu     sync.Mutex
+	levels map[string]zap.AtomicLevel
+	named  map[string]*zap.SugaredLogger
+}
+
+// New constructs a Logger that writes to the configured outputs and provides
+// human-readable timestamps.
+func New(service string, cfg Config) (*Logger, error) {
+	encCfg := zap.NewProductionEncoderConfig()
+	encCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+
+	outputs := cfg.Outputs
+	if len(outputs) == 0 {
+		outputs = []string{"stdout"}
 	}
 
-	log, err := config.Build()
+	sink, closeSink, err := zap.Open(outputs...)
 	if err != nil {
 		return nil, err
 	}
 
-	return log.Sugar(), nil
+	errSink, _, err := zap.Open("stderr")
+	if err != nil {
+		return nil, err
+	}
+
+	log := Logger{
+		cfg:     cfg,
+		encoder: zapcore.NewJSONEncoder(encCfg),
+		sink:    sink,
+		close:   closeSink,
+		opts: []zap.Option{
+			zap.ErrorOutput(errSink),
+			zap.AddCaller(),
+			zap.Fields(zap.String("service", service)),
+		},
+		levels: make(map[string]zap.AtomicLevel),
+		named:  make(map[string]*zap.SugaredLogger),
+	}
+
+	root := zap.NewAtomicLevel()
+	if cfg.Level != "" {
+		if err := root.UnmarshalText([]byte(cfg.Level)); err != nil {
+			return nil, fmt.Errorf("parsing level: %w", err)
+		}
+	}
+	log.levels[RootName] = root
+
+	for name, level := range cfg.Levels {
+		al := zap.NewAtomicLevel()
+		if err := al.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("parsing level for %q: %w", name, err)
+		}
+		log.levels[name] = al
+	}
+
+	log.SugaredLogger = zap.New(log.core(root), log.opts...).Sugar()
+
+	return &log, nil
+}
+
+// Close flushes any buffered logs and closes the outputs. For rotating files
+// it waits for the rotated files to be compressed and pruned. The logger
+// must not be used after it's closed.
+func (l *Logger) Close() error {
+	err := l.Sync()
+	l.close()
+	return err
+}
+
+// Named returns the logger for the specified subsystem. Logs from the
+// subsystem are written with a "logger" field holding its name. Calling
+// Named again with the same name returns the same logger.
+func (l *Logger) Named(name string) *zap.SugaredLogger {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if log, exists := l.named[name]; exists {
+		return log
+	}
+
+	level, exists := l.levels[name]
+	if !exists {
+		level = zap.NewAtomicLevelAt(l.levels[RootName].Level())
+		l.levels[name] = level
+	}
+
+	log := zap.New(l.core(level), l.opts...).Named(name).Sugar()
+	l.named[name] = log
+
+	return log
+}
+
+// Levels returns the current level of every logger by name.
+func (l *Logger) Levels() map[string]string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	levels := make(map[string]string, len(l.levels))
+	for name, level := range l.levels {
+		levels[name] = level.String()
+	}
+
+	return levels
+}
+
+// SetLevel changes the level of the named logger while the application is
+// running. Use RootName for the root logger.
+func (l *Logger) SetLevel(name string, level string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	al, exists := l.levels[name]
+	if !exists {
+		return fmt.Errorf("unknown logger %q", name)
+	}
+
+	var lvl zapcore.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return err
+	}
+	al.SetLevel(lvl)
+
+	return nil
+}
+
+// core constructs a core that writes to the shared output at the level.
+func (l *Logger) core(level zap.AtomicLevel) zapcore.Core {
+	core := zapcore.NewCore(l.encoder.Clone(), l.sink, level)
+
+	if l.cfg.SamplingInitial > 0 && l.cfg.SamplingThereafter > 0 {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, l.cfg.SamplingInitial, l.cfg.SamplingThereafter)
+	}
+
+	return core
 }
@@ -0,0 +1,492 @@
+package logger
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RotateScheme is the URL scheme of the sink that writes to rotating files.
+// The sink is registered with zap so any binary importing this package can
+// use it as an output path.
+//
+//	rotate:///var/log/node.log?maxsize=100&maxage=24h&maxbackups=7&compress=true
+//	rotate:zblock/node.log
+const RotateScheme = "rotate"
+
+// backupTimeFormat is used in the names of the rotated files. It sorts in
+// time order and has no characters that are invalid in a file name.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// rotateRetry is how long to keep writing to the current file after a
+// rotation fails before trying again.
+const rotateRetry = time.Minute
+
+func init() {
+	if err := zap.RegisterSink(RotateScheme, newRotateSink); err != nil {
+		panic(err)
+	}
+}
+
+// RotateConfig provides the settings for writing to rotating files.
+type RotateConfig struct {
+
+	// MaxSize is the size in megabytes a file can grow to before it's
+	// rotated. Zero disables rotation by size.
+	MaxSize int
+
+	// MaxAge is how long a file is written to before it's rotated. Zero
+	// disables rotation by age.
+	MaxAge time.Duration
+
+	// MaxBackups is the number of rotated files to keep. Zero keeps all
+	// of them.
+	MaxBackups int
+
+	// Compress rotated files with gzip.
+	Compress bool
+}
+
+// RotateURL returns the output path for writing the logs to the file with
+// the specified rotation settings.
+func RotateURL(path string, cfg RotateConfig) string {
+	q := url.Values{}
+	if cfg.MaxSize > 0 {
+		q.Set("maxsize", strconv.Itoa(cfg.MaxSize))
+	}
+	if cfg.MaxAge > 0 {
+		q.Set("maxage", cfg.MaxAge.String())
+	}
+	if cfg.MaxBackups > 0 {
+		q.Set("maxbackups", strconv.Itoa(cfg.MaxBackups))
+	}
+	if cfg.Compress {
+		q.Set("compress", "true")
+	}
+
+	u := url.URL{
+		Scheme:   RotateScheme,
+		RawQuery: q.Encode(),
+	}
+
+	// Relative paths are stored as opaque data since a path in a URL with
+	// a scheme is always absolute.
+	if filepath.IsAbs(path) {
+		u.Path = filepath.ToSlash(path)
+	} else {
+		u.Opaque = filepath.ToSlash(path)
+	}
+
+	return u.String()
+}
+
+// newRotateSink constructs the sink for a rotate URL.
+func newRotateSink(u *url.URL) (zap.Sink, error) {
+	path := u.Path
+	if path == "" {
+		path = u.Opaque
+	}
+	if path == "" {
+		return nil, errors.New("rotate sink requires a file path")
+	}
+
+	var cfg RotateConfig
+	q := u.Query()
+
+	if v := q.Get("maxsize"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid maxsize %q", v)
+		}
+		cfg.MaxSize = n
+	}
+
+	if v := q.Get("maxage"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid maxage %q", v)
+		}
+		cfg.MaxAge = d
+	}
+
+	if v := q.Get("maxbackups"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid maxbackups %q", v)
+		}
+		cfg.MaxBackups = n
+	}
+
+	if v := q.Get("compress"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid compress %q", v)
+		}
+		cfg.Compress = b
+	}
+
+	return newRotator(filepath.FromSlash(path), cfg)
+}
+
+// =============================================================================
+
+// rotator is a sink that writes to a file and rotates it once it gets too
+// big or too old. Rotated files are compressed and pruned in the background
+// so logging isn't blocked.
+type rotator struct {
+	path string
+	cfg  RotateConfig
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+	failed time.Time
+	closed bool
+
+	mill chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newRotator opens the file for appending and starts the goroutine that
+// handles the rotated files.
+func newRotator(path string, cfg RotateConfig) (*rotator, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("creating log directory: %w", err)
+	}
+
+	r := rotator{
+		path: path,
+		cfg:  cfg,
+		mill: make(chan struct{}, 1),
+	}
+
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		for range r.mill {
+			r.millRotated()
+		}
+	}()
+
+	// Handle files rotated before a restart that weren't compressed or
+	// pruned yet.
+	r.mill <- struct{}{}
+
+	return &r, nil
+}
+
+// Write implements the io.Writer interface. The file is rotated before a
+// write that would take it over the maximum size or once it's too old.
+func (r *rotator) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return 0, os.ErrClosed
+	}
+
+	// A failed rotation can leave no file open, so try to open it again.
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	// A failed rotation is reported but the write still goes to the file
+	// that is open, so logging carries on.
+	if r.shouldRotate(len(p)) {
+		if err := r.rotate(); err != nil {
+			r.failed = time.Now()
+			fmt.Fprintf(os.Stderr, "log rotate: %s\n", err)
+			if r.file == nil {
+				return 0, err
+			}
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+
+	return n, err
+}
+
+// Sync implements the zapcore.WriteSyncer interface.
+func (r *rotator) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+
+	return r.file.Sync()
+}
+
+// Close implements the io.Closer interface. It waits for the rotated files
+// to be handled.
+func (r *rotator) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+
+	var err error
+	if r.file != nil {
+		err = r.file.Close()
+		r.file = nil
+	}
+	r.closed = true
+	close(r.mill)
+	r.mu.Unlock()
+
+	r.wg.Wait()
+
+	return err
+}
+
+// open opens the file for appending and records its size. A file that
+// already exists is assumed to have been opened when it was last changed
+// so age based rotation carries over a restart.
+func (r *rotator) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("opening log file: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("reading log file info: %w", err)
+	}
+
+	r.file = f
+	r.size = info.Size()
+	r.opened = time.Now()
+	if info.Size() > 0 {
+		r.opened = info.ModTime()
+	}
+
+	return nil
+}
+
+// shouldRotate reports whether the file needs to be rotated before writing
+// the specified number of bytes. An empty file is never rotated so a single
+// write larger than the maximum size still gets written.
+func (r *rotator) shouldRotate(n int) bool {
+	if r.size == 0 {
+		return false
+	}
+
+	if time.Since(r.failed) < rotateRetry {
+		return false
+	}
+
+	if r.cfg.MaxSize > 0 && r.size+int64(n) > int64(r.cfg.MaxSize)*1024*1024 {
+		return true
+	}
+
+	if r.cfg.MaxAge > 0 && time.Since(r.opened) >= r.cfg.MaxAge {
+		return true
+	}
+
+	return false
+}
+
+// rotate renames the current file using the time of the rotation and opens
+// a new file. If any step fails the current file is opened again so logging
+// carries on in it. The caller must hold the lock.
+func (r *rotator) rotate() error {
+	backup := r.backupName(time.Now().UTC())
+
+	if err := r.file.Close(); err != nil {
+		return r.reopen(fmt.Errorf("closing log file: %w", err))
+	}
+
+	if err := os.Rename(r.path, backup); err != nil {
+		return r.reopen(fmt.Errorf("renaming log file: %w", err))
+	}
+
+	if err := r.open(); err != nil {
+
+		// Put the file back so the logs keep going to the same file.
+		if rerr := os.Rename(backup, r.path); rerr != nil {
+			err = fmt.Errorf("%w: restoring log file: %s", err, rerr)
+		}
+		return r.reopen(err)
+	}
+
+	// Don't block if the rotated files are already being handled, since
+	// every pass handles all of them.
+	select {
+	case r.mill <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// reopen opens the current file again after a failed rotation and returns
+// the error that caused the failure. If the file can't be opened there is no
+// file until a later write manages to open it. The caller must hold the lock.
+func (r *rotator) reopen(err error) error {
+	if oerr := r.open(); oerr != nil {
+		r.file = nil
+		return fmt.Errorf("%w: %s", err, oerr)
+	}
+	return err
+}
+
+// backupName returns the name for a file rotated at the specified time.
+func (r *rotator) backupName(t time.Time) string {
+	dir := filepath.Dir(r.path)
+	ext := filepath.Ext(r.path)
+	prefix := strings.TrimSuffix(filepath.Base(r.path), ext)
+
+	return filepath.Join(dir, prefix+"-"+t.Format(backupTimeFormat)+ext)
+}
+
+// =============================================================================
+
+// millRotated compresses the rotated files when configured and removes the
+// oldest files beyond the number to keep. Errors are written to stderr
+// since the sink can't log its own failures.
+func (r *rotator) millRotated() {
+	backups, err := r.backups()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "log rotate: listing rotated files: %s\n", err)
+		return
+	}
+
+	if r.cfg.MaxBackups > 0 && len(backups) > r.cfg.MaxBackups {
+		for _, name := range backups[r.cfg.MaxBackups:] {
+			if err := os.Remove(name); err != nil {
+				fmt.Fprintf(os.Stderr, "log rotate: removing %s: %s\n", name, err)
+			}
+		}
+		backups = backups[:r.cfg.MaxBackups]
+	}
+
+	if !r.cfg.Compress {
+		return
+	}
+
+	for _, name := range backups {
+		if strings.HasSuffix(name, ".gz") {
+			continue
+		}
+		if err := compress(name); err != nil {
+			fmt.Fprintf(os.Stderr, "log rotate: compressing %s: %s\n", name, err)
+		}
+	}
+}
+
+// backups returns the rotated files, newest first.
+func (r *rotator) backups() ([]string, error) {
+	dir := filepath.Dir(r.path)
+	ext := filepath.Ext(r.path)
+	prefix := strings.TrimSuffix(filepath.Base(r.path), ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	type backup struct {
+		name string
+		t    time.Time
+	}
+
+	var list []backup
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		ts := strings.TrimPrefix(name, prefix)
+		ts = strings.TrimSuffix(ts, ".gz")
+		ts = strings.TrimSuffix(ts, ext)
+
+		t, err := time.Parse(backupTimeFormat, ts)
+		if err != nil {
+			continue
+		}
+
+		list = append(list, backup{name: filepath.Join(dir, name), t: t})
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].t.After(list[j].t)
+	})
+
+	names := make([]string, len(list))
+	for i, b := range list {
+		names[i] = b.name
+	}
+
+	return names, nil
+}
+
+// compress writes a gzip copy of the file and removes the original. The
+// copy is written to a temporary name first so a partial file is never
+// mistaken for a complete one.
+func compress(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := name + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		dst.Close()
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := dst.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, name+".gz"); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	src.Close()
+	return os.Remove(name)
+}
@@ -0,0 +1,329 @@
+// Package tracer provides support for W3C Trace Context propagation and for
+// recording spans, so a request can be followed across several nodes.
+// https://w3c.github.io/trace-context/
+package tracer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Set of headers used to propagate the trace context.
+const (
+	HeaderTraceParent = "traceparent"
+	HeaderTraceState  = "tracestate"
+)
+
+// Set of zero ids, which are invalid in a trace context.
+const (
+	zeroTraceID = "00000000000000000000000000000000"
+	zeroSpanID  = "0000000000000000"
+)
+
+// flagSampled is the trace flag marking the trace as sampled.
+const flagSampled = 0x01
+
+// =============================================================================
+
+// SpanContext represents the part of a span that is propagated between
+// services.
+type SpanContext struct {
+	TraceID string
+	SpanID  string
+	Flags   byte
+	State   string
+}
+
+// Sampled reports whether the caller recorded the trace.
+func (sc SpanContext) Sampled() bool {
+	return sc.Flags&flagSampled != 0
+}
+
+// TraceParent returns the value for the traceparent header.
+func (sc SpanContext) TraceParent() string {
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceID, sc.SpanID, sc.Flags)
+}
+
+// Extract reads the trace context from the headers. It returns false if the
+// headers don't carry a valid traceparent.
+func Extract(h http.Header) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(h.Get(HeaderTraceParent)), "-")
+	if len(parts) < 4 {
+		return SpanContext{}, false
+	}
+
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+
+	// Version 00 has exactly four parts and ff is never valid. Later
+	// versions may add parts which are ignored.
+	if !isHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return SpanContext{}, false
+	}
+
+	if !isHex(traceID, 32) || traceID == zeroTraceID {
+		return SpanContext{}, false
+	}
+
+	if !isHex(spanID, 16) || spanID == zeroSpanID {
+		return SpanContext{}, false
+	}
+
+	if !isHex(flags, 2) {
+		return SpanContext{}, false
+	}
+
+	b, _ := hex.DecodeString(flags)
+
+	sc := SpanContext{
+		TraceID: traceID,
+		SpanID:  spanID,
+		Flags:   b[0],
+		State:   strings.TrimSpace(strings.Join(h.Values(HeaderTraceState), ",")),
+	}
+
+	return sc, true
+}
+
+// Inject writes the trace context of the span stored in the context into
+// the headers, so the callee continues the trace.
+func Inject(ctx context.Context, h http.Header) {
+	sc, ok := spanContext(ctx)
+	if !ok {
+		return
+	}
+
+	h.Set(HeaderTraceParent, sc.TraceParent())
+	if sc.State != "" {
+		h.Set(HeaderTraceState, sc.State)
+	}
+}
+
+// isHex validates the value is lowercase hex of the specified length.
+func isHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+
+	for _, c := range []byte(s) {
+		if !('0' <= c && c <= '9') && !('a' <= c && c <= 'f') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newID returns a random id of the specified number of bytes as hex.
+func newID(bytes int) string {
+	b := make([]byte, bytes)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("reading random bytes: %s", err))
+	}
+	return hex.EncodeToString(b)
+}
+
+// =============================================================================
+
+// ctxKey represents the type of value for the context key.
+type ctxKey int
+
+// Set of keys for values stored in the context.
+const (
+	spanKey ctxKey = iota + 1
+	remoteKey
+)
+
+// ContextWithRemote stores the trace context received from a caller so the
+// next span started is its child.
+func ContextWithRemote(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey, sc)
+}
+
+// SpanFromContext returns the current span from the context.
+func SpanFromContext(ctx context.Context) (*Span, bool) {
+	span, ok := ctx.Value(spanKey).(*Span)
+	return span, ok
+}
+
+// spanContext returns the trace context of the current span, or of the
+// remote caller if no span was started yet.
+func spanContext(ctx context.Context) (SpanContext, bool) {
+	if span, ok := SpanFromContext(ctx); ok {
+		return span.Context(), true
+	}
+
+	sc, ok := ctx.Value(remoteKey).(SpanContext)
+	return sc, ok
+}
+
+// =============================================================================
+
+// Span represents a unit of work within a trace.
+type Span struct {
+	tracer *Tracer
+	sc     SpanContext
+	data   SpanData
+	mu     sync.Mutex
+	ended  bool
+}
+
+// SpanData represents the information for a span that is exported.
+type SpanData struct {
+	Service      string         `json:"service"`
+	Name         string         `json:"name"`
+	TraceID      string         `json:"trace_id"`
+	SpanID       string         `json:"span_id"`
+	ParentSpanID string         `json:"parent_span_id,omitempty"`
+	Start        time.Time      `json:"start"`
+	End          time.Time      `json:"end"`
+	DurationMS   float64        `json:"duration_ms"`
+	Attributes   map[string]any `json:"attributes,omitempty"`
+}
+
+// Context returns the trace context of the span for propagation.
+func (s *Span) Context() SpanContext {
+	return s.sc
+}
+
+// TraceID returns the id of the trace the span belongs to.
+func (s *Span) TraceID() string {
+	return s.sc.TraceID
+}
+
+// SetAttribute records a key/value pair on the span.
+func (s *Span) SetAttribute(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.data.Attributes == nil {
+		s.data.Attributes = make(map[string]any)
+	}
+	s.data.Attributes[key] = value
+}
+
+// End completes the span and exports it if the trace is sampled. Calling
+// End more than once has no effect.
+func (s *Span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now().UTC()
+	s.data.DurationMS = float64(s.data.End.Sub(s.data.Start)) / float64(time.Millisecond)
+	data := s.data
+	s.mu.Unlock()
+
+	if s.sc.Sampled() {
+		s.tracer.export(data)
+	}
+}
+
+// =============================================================================
+
+// Exporter is the behavior required to send finished spans somewhere.
+type Exporter interface {
+	Export(span SpanData) error
+}
+
+// Tracer starts spans and hands them to the exporter when they end.
+type Tracer struct {
+	service  string
+	exporter Exporter
+	onError  func(error)
+}
+
+// New constructs a tracer for the service. A nil exporter propagates the
+// trace context without exporting any spans. The error function is called
+// when a span can't be exported.
+func New(service string, exporter Exporter, onError func(error)) *Tracer {
+	return &Tracer{
+		service:  service,
+		exporter: exporter,
+		onError:  onError,
+	}
+}
+
+// Start begins a new span as a child of the span or remote caller in the
+// context. If there is neither, a new sampled trace is started.
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, *Span) {
+	sc := SpanContext{
+		SpanID: newID(8),
+	}
+
+	var parentSpanID string
+	if parent, ok := spanContext(ctx); ok {
+		sc.TraceID = parent.TraceID
+		sc.Flags = parent.Flags
+		sc.State = parent.State
+		parentSpanID = parent.SpanID
+	} else {
+		sc.TraceID = newID(16)
+		sc.Flags = flagSampled
+	}
+
+	span := Span{
+		tracer: t,
+		sc:     sc,
+		data: SpanData{
+			Service:      t.service,
+			Name:         name,
+			TraceID:      sc.TraceID,
+			SpanID:       sc.SpanID,
+			ParentSpanID: parentSpanID,
+			Start:        time.Now().UTC(),
+		},
+	}
+
+	return context.WithValue(ctx, spanKey, &span), &span
+}
+
+// export hands the span to the exporter.
+func (t *Tracer) export(data SpanData) {
+	if t.exporter == nil {
+		return
+	}
+
+	if err := t.exporter.Export(data); err != nil && t.onError != nil {
+		t.onError(err)
+	}
+}
+
+// =============================================================================
+
+// WriterExporter writes each span as a line of JSON. It can be used with
+// stdout or a file.
+type WriterExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterExporter constructs an exporter that writes to the writer.
+func NewWriterExporter(w io.Writer) *WriterExporter {
+	return &WriterExporter{
+		w: w,
+	}
+}
+
+// Export implements the Exporter interface.
+func (we *WriterExporter) Export(span SpanData) error {
+	data, err := json.Marshal(span)
+	if err != nil {
+		return err
+	}
+
+	we.mu.Lock()
+	defer we.mu.Unlock()
+
+	_, err = we.w.Write(append(data, '\n'))
+	return err
+}
@@ -12,9 +12,17 @@ type ctxKey int
 // key is how request values are stored/retrieved.
 const key ctxKey = 1
 
+// validatorKey is how the app's validator is stored/retrieved for Decode.
+const validatorKey ctxKey = 2
+
+// encodingKey is how the negotiated response encoding is stored/retrieved
+// for Respond.
+const encodingKey ctxKey = 3
+
 // Values represent state for each request.
 type Values struct {
 	TraceID    string
+	Route      string
 	Now        time.Time
 	StatusCode int
 }
@@ -32,7 +40,7 @@ func GetValues(ctx context.Context) (*Values, error) {
 func GetTraceID(ctx context.Context) string {
 	v, ok := ctx.Value(key).(*Values)
 	if !ok {
-		return "00000000-0000-0000-0000-000000000000"
+		return "00000000000000000000000000000000"
 	}
 	return v.TraceID
 }
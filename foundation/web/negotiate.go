@@ -0,0 +1,105 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Set of content types Respond can encode.
+const (
+	contentTypeJSON = "application/json"
+	contentTypeCBOR = "application/cbor"
+)
+
+// gzipMinSize is the smallest response that is compressed. Smaller responses
+// don't get any smaller.
+const gzipMinSize = 1024
+
+// encoding represents how Respond encodes the response, as negotiated with
+// the client.
+type encoding struct {
+	contentType string
+	pretty      bool
+	gzip        bool
+}
+
+// negotiate decides the response encoding from the request. JSON is used
+// unless the client prefers CBOR, and is indented if the pretty query
+// parameter is present.
+func negotiate(r *http.Request) encoding {
+	enc := encoding{
+		contentType: contentTypeJSON,
+		pretty:      r.URL.Query().Has("pretty"),
+		gzip:        accepts(r.Header.Get("Accept-Encoding"), "gzip") > 0,
+	}
+
+	accept := r.Header.Get("Accept")
+	if cbor := accepts(accept, contentTypeCBOR); cbor > 0 && cbor > accepts(accept, contentTypeJSON) {
+		enc.contentType = contentTypeCBOR
+	}
+
+	return enc
+}
+
+// accepts returns the quality the header value gives to the token. Wildcards
+// match with a lower precedence than the token itself, and a quality of zero
+// means the token isn't acceptable.
+func accepts(header string, token string) float64 {
+	if header == "" {
+		return 0
+	}
+
+	typ, _, _ := strings.Cut(token, "/")
+
+	quality := 0.0
+	specificity := -1
+
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		value := strings.ToLower(strings.TrimSpace(params[0]))
+
+		var spec int
+		switch value {
+		case token:
+			spec = 2
+		case typ + "/*":
+			spec = 1
+		case "*/*", "*":
+			spec = 0
+		default:
+			continue
+		}
+
+		// The most specific match decides the quality.
+		if spec < specificity {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range params[1:] {
+			k, v, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.ToLower(k) == "q" {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					q = f
+				}
+			}
+		}
+
+		specificity = spec
+		quality = q
+	}
+
+	return quality
+}
+
+// getEncoding returns the negotiated encoding from the context. Compact JSON
+// is used if nothing was negotiated.
+func getEncoding(ctx context.Context) encoding {
+	enc, ok := ctx.Value(encodingKey).(encoding)
+	if !ok {
+		return encoding{contentType: contentTypeJSON}
+	}
+	return enc
+}
@@ -0,0 +1,105 @@
+package web
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+// Success and failure markers.
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+func TestAccepts(t *testing.T) {
+	type table struct {
+		name   string
+		header string
+		token  string
+		exp    float64
+	}
+
+	tt := []table{
+		{name: "empty", header: "", token: contentTypeJSON, exp: 0},
+		{name: "exact", header: "application/json", token: contentTypeJSON, exp: 1},
+		{name: "case", header: "Application/JSON; Q=0.5", token: contentTypeJSON, exp: 0.5},
+		{name: "no match", header: "text/html", token: contentTypeJSON, exp: 0},
+		{name: "quality", header: "application/cbor;q=0.9, application/json;q=0.5", token: contentTypeCBOR, exp: 0.9},
+		{name: "any type", header: "*/*;q=0.3", token: contentTypeJSON, exp: 0.3},
+		{name: "any subtype", header: "application/*;q=0.4, */*;q=0.1", token: contentTypeJSON, exp: 0.4},
+		{name: "exact before wildcard", header: "*/*;q=0.2, application/json;q=0.7", token: contentTypeJSON, exp: 0.7},
+		{name: "refused", header: "application/json;q=0, */*", token: contentTypeJSON, exp: 0},
+		{name: "refused by wildcard", header: "*/*;q=0", token: contentTypeCBOR, exp: 0},
+		{name: "encoding", header: "deflate, gzip;q=0.8", token: "gzip", exp: 0.8},
+		{name: "encoding wildcard", header: "*", token: "gzip", exp: 1},
+		{name: "encoding refused", header: "gzip;q=0, *", token: "gzip", exp: 0},
+	}
+
+	t.Log("Given the need to read the quality a header gives a value.")
+	{
+		for testID, tst := range tt {
+			f := func(t *testing.T) {
+				t.Logf("\tTest %d:\tWhen checking %q for %s.", testID, tst.header, tst.token)
+				{
+					got := accepts(tst.header, tst.token)
+					if got != tst.exp {
+						t.Fatalf("\t%s\tTest %d:\tShould get a quality of %v : got %v.", failed, testID, tst.exp, got)
+					}
+					t.Logf("\t%s\tTest %d:\tShould get a quality of %v.", success, testID, tst.exp)
+				}
+			}
+
+			t.Run(tst.name, f)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	type table struct {
+		name           string
+		url            string
+		accept         string
+		acceptEncoding string
+		exp            encoding
+	}
+
+	tt := []table{
+		{name: "default", url: "/", exp: encoding{contentType: contentTypeJSON}},
+		{name: "pretty", url: "/?pretty", exp: encoding{contentType: contentTypeJSON, pretty: true}},
+		{name: "cbor", url: "/", accept: "application/cbor", exp: encoding{contentType: contentTypeCBOR}},
+		{name: "tie", url: "/", accept: "application/cbor, application/json", exp: encoding{contentType: contentTypeJSON}},
+		{name: "cbor preferred", url: "/", accept: "application/json;q=0.5, application/cbor", exp: encoding{contentType: contentTypeCBOR}},
+		{name: "cbor refused", url: "/", accept: "application/cbor;q=0", exp: encoding{contentType: contentTypeJSON}},
+		{name: "any", url: "/", accept: "*/*", exp: encoding{contentType: contentTypeJSON}},
+		{name: "gzip", url: "/", acceptEncoding: "gzip, deflate", exp: encoding{contentType: contentTypeJSON, gzip: true}},
+		{name: "gzip wildcard", url: "/", acceptEncoding: "*", exp: encoding{contentType: contentTypeJSON, gzip: true}},
+		{name: "gzip refused", url: "/", acceptEncoding: "gzip;q=0", exp: encoding{contentType: contentTypeJSON}},
+		{name: "everything", url: "/?pretty", accept: "application/cbor", acceptEncoding: "gzip", exp: encoding{contentType: contentTypeCBOR, pretty: true, gzip: true}},
+	}
+
+	t.Log("Given the need to negotiate the response encoding.")
+	{
+		for testID, tst := range tt {
+			f := func(t *testing.T) {
+				t.Logf("\tTest %d:\tWhen requesting %s with Accept %q and Accept-Encoding %q.", testID, tst.url, tst.accept, tst.acceptEncoding)
+				{
+					r := httptest.NewRequest("GET", tst.url, nil)
+					if tst.accept != "" {
+						r.Header.Set("Accept", tst.accept)
+					}
+					if tst.acceptEncoding != "" {
+						r.Header.Set("Accept-Encoding", tst.acceptEncoding)
+					}
+
+					got := negotiate(r)
+					if got != tst.exp {
+						t.Fatalf("\t%s\tTest %d:\tShould get the encoding %+v : got %+v.", failed, testID, tst.exp, got)
+					}
+					t.Logf("\t%s\tTest %d:\tShould get the encoding %+v.", success, testID, tst.exp)
+				}
+			}
+
+			t.Run(tst.name, f)
+		}
+	}
+}
@@ -0,0 +1,349 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenAPI represents an OpenAPI 3 document.
+type OpenAPI struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       OpenAPIInfo                     `json:"info"`
+	Paths      map[string]map[string]Operation `json:"paths"`
+	Components Components                      `json:"components"`
+}
+
+// OpenAPIInfo provides the metadata about the API.
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// Operation describes a single API operation on a path.
+type Operation struct {
+	Summary     string              `json:"summary,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+// Parameter describes a single path or query parameter.
+type Parameter struct {
+	Name        string `json:"name"`
+	In          string `json:"in"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required"`
+	Schema      Schema `json:"schema"`
+}
+
+// RequestBody describes the body of a request.
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response describes a single response from an operation.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType provides the schema for a content type.
+type MediaType struct {
+	Schema Schema `json:"schema"`
+}
+
+// Components holds the schemas referenced by the operations.
+type Components struct {
+	Schemas map[string]Schema `json:"schemas"`
+}
+
+// Schema represents a JSON schema object.
+type Schema map[string]any
+
+// =============================================================================
+
+// OpenAPI builds an OpenAPI 3 document for the routes registered with the app.
+// OPTIONS routes are skipped since they only exist for CORS preflight. An
+// error naming the routes is returned if any other route isn't documented.
+func (a *App) OpenAPI(title string, version string) (OpenAPI, error) {
+	doc := OpenAPI{
+		OpenAPI: "3.0.3",
+		Info: OpenAPIInfo{
+			Title:   title,
+			Version: version,
+		},
+		Paths: make(map[string]map[string]Operation),
+		Components: Components{
+			Schemas: make(map[string]Schema),
+		},
+	}
+
+	sg := schemaGen{
+		schemas: doc.Components.Schemas,
+		names:   make(map[string]reflect.Type),
+	}
+
+	var missing []string
+	for _, route := range a.routes {
+		if route.Method == http.MethodOptions {
+			continue
+		}
+
+		if route.Doc == nil {
+			missing = append(missing, route.Method+" "+route.Path)
+			continue
+		}
+
+		apiPath, params := openAPIPath(route.Path)
+		op := sg.operation(route, params)
+
+		if doc.Paths[apiPath] == nil {
+			doc.Paths[apiPath] = make(map[string]Operation)
+		}
+		doc.Paths[apiPath][strings.ToLower(route.Method)] = op
+	}
+
+	if len(missing) > 0 {
+		return OpenAPI{}, fmt.Errorf("routes missing documentation: %s", strings.Join(missing, ", "))
+	}
+
+	return doc, nil
+}
+
+// openAPIPath converts the router's path syntax into the OpenAPI syntax and
+// returns the names of the path parameters.
+func openAPIPath(routePath string) (string, []string) {
+	var params []string
+
+	parts := strings.Split(routePath, "/")
+	for i, part := range parts {
+		if len(part) < 2 || (part[0] != ':' && part[0] != '*') {
+			continue
+		}
+
+		params = append(params, part[1:])
+		parts[i] = "{" + part[1:] + "}"
+	}
+
+	return strings.Join(parts, "/"), params
+}
+
+// =============================================================================
+
+// schemaGen generates schemas from Go types. Named struct types are added to
+// the components and referenced so recursive types are supported.
+type schemaGen struct {
+	schemas map[string]Schema
+	names   map[string]reflect.Type
+}
+
+// operation builds the operation for a documented route.
+func (sg schemaGen) operation(route *Route, params []string) Operation {
+	d := route.Doc
+
+	op := Operation{
+		Summary:     d.Summary,
+		Description: d.Description,
+		Responses:   make(map[string]Response),
+	}
+
+	for _, name := range params {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   Schema{"type": "string"},
+		})
+	}
+
+	names := make([]string, 0, len(d.Query))
+	for name := range d.Query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:        name,
+			In:          "query",
+			Description: d.Query[name],
+			Schema:      Schema{"type": "string"},
+		})
+	}
+
+	if d.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]MediaType{
+				"application/json": {Schema: sg.schema(reflect.TypeOf(d.Request))},
+			},
+		}
+	}
+
+	status := d.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	resp := Response{
+		Description: http.StatusText(status),
+	}
+
+	if d.Response != nil {
+		contentType := d.ContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+
+		resp.Content = map[string]MediaType{
+			contentType: {Schema: sg.schema(reflect.TypeOf(d.Response))},
+		}
+	}
+
+	op.Responses[strconv.Itoa(status)] = resp
+
+	return op
+}
+
+// Set of types with a custom JSON representation.
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	bigIntType     = reflect.TypeOf(big.Int{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schema returns the schema for the specified type.
+func (sg schemaGen) schema(t reflect.Type) Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return Schema{"type": "string", "format": "date-time"}
+	case bigIntType:
+		return Schema{"type": "integer"}
+	case rawMessageType:
+		return Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer"}
+
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+
+	case reflect.String:
+		return Schema{"type": "string"}
+
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Schema{"type": "string", "format": "byte"}
+		}
+		return Schema{"type": "array", "items": sg.schema(t.Elem())}
+
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": sg.schema(t.Elem())}
+
+	case reflect.Struct:
+		if t.Name() == "" {
+			return sg.object(t)
+		}
+		return sg.ref(t)
+	}
+
+	// Interfaces and anything else can hold any value.
+	return Schema{}
+}
+
+// ref adds the named struct type to the components and returns a reference.
+func (sg schemaGen) ref(t reflect.Type) Schema {
+	name := path.Base(t.PkgPath()) + "." + t.Name()
+	for i := 2; sg.names[name] != nil && sg.names[name] != t; i++ {
+		name = fmt.Sprintf("%s.%s%d", path.Base(t.PkgPath()), t.Name(), i)
+	}
+
+	ref := Schema{"$ref": "#/components/schemas/" + name}
+	if sg.names[name] != nil {
+		return ref
+	}
+
+	// Register the name first so recursive types find the reference.
+	sg.names[name] = t
+	sg.schemas[name] = sg.object(t)
+
+	return ref
+}
+
+// object builds the schema for the fields of a struct type.
+func (sg schemaGen) object(t reflect.Type) Schema {
+	props := make(map[string]Schema)
+	var required []string
+
+	sg.fields(t, props, &required)
+
+	s := Schema{
+		"type":       "object",
+		"properties": props,
+	}
+	if len(required) > 0 {
+		sort.Strings(required)
+		s["required"] = required
+	}
+
+	return s
+}
+
+// fields adds the JSON fields of the struct type to the properties,
+// flattening embedded structs the same way encoding/json does.
+func (sg schemaGen) fields(t reflect.Type, props map[string]Schema, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		fld := t.Field(i)
+
+		tag := fld.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if fld.Anonymous && name == "" {
+			ft := fld.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				sg.fields(ft, props, required)
+				continue
+			}
+		}
+
+		if !fld.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = fld.Name
+		}
+
+		props[name] = sg.schema(fld.Type)
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
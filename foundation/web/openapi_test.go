@@ -0,0 +1,42 @@
+package web_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/ardanlabs/blockchain/foundation/tracer"
+	"github.com/ardanlabs/blockchain/foundation/web"
+	"go.uber.org/zap"
+)
+
+func TestOpenAPIUndocumented(t *testing.T) {
+	h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}
+
+	t.Log("Given the need to fail on routes without documentation.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen one route isn't documented.", testID)
+		{
+			app := web.NewApp(make(chan os.Signal, 1), zap.NewNop().Sugar(), tracer.New("TEST", nil, nil))
+			app.Handle(http.MethodGet, "v1", "/documented", h).Document(web.Doc{Summary: "Documented route"})
+			app.Handle(http.MethodGet, "v1", "/undocumented", h)
+			app.Handle(http.MethodOptions, "", "/*", h)
+
+			_, err := app.OpenAPI("Test API", "v1")
+			if err == nil {
+				t.Fatalf("\t%s\tTest %d:\tShould fail to build the OpenAPI document.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould fail to build the OpenAPI document.", success, testID)
+
+			exp := "routes missing documentation: GET /v1/undocumented"
+			if err.Error() != exp {
+				t.Fatalf("\t%s\tTest %d:\tShould only name the undocumented route : got %q.", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould only name the undocumented route.", success, testID)
+		}
+	}
+}
@@ -2,7 +2,12 @@ package web
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"reflect"
+	"strings"
 
 	"github.com/dimfeld/httptreemux/v5"
 )
@@ -16,13 +21,114 @@ func Param(r *http.Request, key string) string {
 // Decode reads the body of an HTTP request looking for a JSON document. The
 // body is decoded into the provided value.
 //
-// If the provided value is a struct then it is checked for validation tags.
+// If the provided value is a struct then it is checked with the validator
+// set on the App. Problems with the body are returned as a DecodeError
+// describing the problem for the client.
 func Decode(r *http.Request, val any) error {
 	decoder := json.NewDecoder(r.Body)
 	decoder.DisallowUnknownFields()
 	if err := decoder.Decode(val); err != nil {
-		return err
+		return decodeError(err)
+	}
+
+	// The body must hold a single JSON document.
+	if err := decoder.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			return decodeError(err)
+		}
+		return NewDecodeError(http.StatusBadRequest, "body must only contain a single JSON value")
+	}
+
+	if v, ok := r.Context().Value(validatorKey).(Validator); ok && isStruct(val) {
+		if err := v(val); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
+
+// decodeError converts an error from the JSON decoder into a message the
+// client can act on. Errors not caused by the body are returned as is.
+func decodeError(err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var mbe *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &syntaxErr):
+		return NewDecodeError(http.StatusBadRequest, fmt.Sprintf("body contains badly-formed JSON (at position %d)", syntaxErr.Offset))
+
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return NewDecodeError(http.StatusBadRequest, "body contains badly-formed JSON")
+
+	case errors.As(err, &typeErr):
+		if typeErr.Field != "" {
+			return NewDecodeError(http.StatusBadRequest, fmt.Sprintf("body contains an invalid value for the %q field, expected %s (at position %d)", typeErr.Field, typeErr.Type, typeErr.Offset))
+		}
+		return NewDecodeError(http.StatusBadRequest, fmt.Sprintf("body contains an invalid value, expected %s (at position %d)", typeErr.Type, typeErr.Offset))
+
+	case errors.Is(err, io.EOF):
+		return NewDecodeError(http.StatusBadRequest, "body must not be empty")
+
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.TrimPrefix(err.Error(), "json: unknown field ")
+		return NewDecodeError(http.StatusBadRequest, fmt.Sprintf("body contains unknown field %s", field))
+
+	case errors.As(err, &mbe):
+		return NewDecodeError(http.StatusRequestEntityTooLarge, fmt.Sprintf("body must not be larger than %d bytes", mbe.Limit))
+	}
+
+	return err
+}
+
+// isStruct reports whether the value is a struct or a pointer to one.
+func isStruct(val any) bool {
+	v := reflect.ValueOf(val)
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	return v.Kind() == reflect.Struct
+}
+
+// =============================================================================
+
+// Validator checks a decoded value and returns an error describing any
+// problems found.
+type Validator func(val any) error
+
+// DecodeError is used to pass an error about the request body back to the
+// client, along with the status code to respond with.
+type DecodeError struct {
+	Status int
+	Msg    string
+}
+
+// NewDecodeError constructs an error about the request body.
+func NewDecodeError(status int, msg string) error {
+	return &DecodeError{
+		Status: status,
+		Msg:    msg,
+	}
+}
+
+// Error implements the error interface.
+func (de *DecodeError) Error() string {
+	return de.Msg
+}
+
+// IsDecodeError checks if an error of type DecodeError exists.
+func IsDecodeError(err error) bool {
+	var de *DecodeError
+	return errors.As(err, &de)
+}
+
+// GetDecodeError returns a copy of the DecodeError pointer.
+func GetDecodeError(err error) *DecodeError {
+	var de *DecodeError
+	if !errors.As(err, &de) {
+		return nil
+	}
+	return de
+}
@@ -0,0 +1,73 @@
+package web_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ardanlabs/blockchain/foundation/web"
+)
+
+func TestDecode(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+
+	type table struct {
+		name   string
+		body   string
+		limit  int64
+		status int
+		msg    string
+	}
+
+	tt := []table{
+		{name: "syntax error", body: `{"name":}`, status: http.StatusBadRequest, msg: "body contains badly-formed JSON (at position 9)"},
+		{name: "unexpected end", body: `{"name":"bill"`, status: http.StatusBadRequest, msg: "body contains badly-formed JSON"},
+		{name: "type error", body: `{"age":"ten"}`, status: http.StatusBadRequest, msg: `body contains an invalid value for the "age" field, expected int (at position 12)`},
+		{name: "unknown field", body: `{"email":"bill@example.com"}`, status: http.StatusBadRequest, msg: `body contains unknown field "email"`},
+		{name: "empty body", body: ``, status: http.StatusBadRequest, msg: "body must not be empty"},
+		{name: "trailing data", body: `{"name":"bill"}{"name":"ed"}`, status: http.StatusBadRequest, msg: "body must only contain a single JSON value"},
+		{name: "oversized body", body: `{"name":"` + strings.Repeat("a", 64) + `"}`, limit: 32, status: http.StatusRequestEntityTooLarge, msg: "body must not be larger than 32 bytes"},
+		{name: "oversized trailing data", body: `{"name":"bill"}` + strings.Repeat(" ", 64), limit: 32, status: http.StatusRequestEntityTooLarge, msg: "body must not be larger than 32 bytes"},
+	}
+
+	t.Log("Given the need to explain problems with a request body.")
+	{
+		for testID, tst := range tt {
+			f := func(t *testing.T) {
+				t.Logf("\tTest %d:\tWhen decoding a body with a %s.", testID, tst.name)
+				{
+					r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tst.body))
+					if tst.limit > 0 {
+						r.Body = http.MaxBytesReader(httptest.NewRecorder(), r.Body, tst.limit)
+					}
+
+					var u user
+					err := web.Decode(r, &u)
+
+					var de *web.DecodeError
+					if !errors.As(err, &de) {
+						t.Fatalf("\t%s\tTest %d:\tShould get a decode error : got %v.", failed, testID, err)
+					}
+					t.Logf("\t%s\tTest %d:\tShould get a decode error.", success, testID)
+
+					if de.Status != tst.status {
+						t.Fatalf("\t%s\tTest %d:\tShould get a %d status : got %d.", failed, testID, tst.status, de.Status)
+					}
+					t.Logf("\t%s\tTest %d:\tShould get a %d status.", success, testID, tst.status)
+
+					if de.Msg != tst.msg {
+						t.Fatalf("\t%s\tTest %d:\tShould get the message %q : got %q.", failed, testID, tst.msg, de.Msg)
+					}
+					t.Logf("\t%s\tTest %d:\tShould get the message %q.", success, testID, tst.msg)
+				}
+			}
+
+			t.Run(tst.name, f)
+		}
+	}
+}
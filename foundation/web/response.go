@@ -1,12 +1,17 @@
 package web
 
 import (
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"net/http"
+
+	"github.com/ardanlabs/blockchain/foundation/cbor"
 )
 
-// Respond converts a Go value to JSON and sends it to the client.
+// Respond converts a Go value to JSON, or to CBOR if the client prefers it,
+// and sends it to the client. The response is compressed when the client
+// accepts gzip.
 func Respond(ctx context.Context, w http.ResponseWriter, data any, statusCode int) error {
 
 	// Set the status code for the request logger middleware.
@@ -18,22 +23,52 @@ func Respond(ctx context.Context, w http.ResponseWriter, data any, statusCode in
 		return nil
 	}
 
-	// Convert the response value to JSON.
-	jsonData, err := json.MarshalIndent(data, "", "    ")
+	// Convert the response value to the negotiated encoding.
+	enc := getEncoding(ctx)
+	body, err := marshal(enc, data)
 	if err != nil {
 		return err
 	}
 
 	// Set the content type and headers once we know marshaling has succeeded.
-	w.Header().Set("Content-Type", "application/json")
+	// The response depends on these request headers, so caches need to know.
+	w.Header().Set("Content-Type", enc.contentType)
+	w.Header().Add("Vary", "Accept")
+	w.Header().Add("Vary", "Accept-Encoding")
+
+	if enc.gzip && len(body) >= gzipMinSize {
+		w.Header().Set("Content-Encoding", "gzip")
+
+		// Write the status code to the response.
+		w.WriteHeader(statusCode)
+
+		// Send the compressed result back to the client.
+		gz := gzip.NewWriter(w)
+		if _, err := gz.Write(body); err != nil {
+			return err
+		}
+		return gz.Close()
+	}
 
 	// Write the status code to the response.
 	w.WriteHeader(statusCode)
 
 	// Send the result back to the client.
-	if _, err := w.Write(jsonData); err != nil {
+	if _, err := w.Write(body); err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// marshal converts the value using the encoding.
+func marshal(enc encoding, data any) ([]byte, error) {
+	switch {
+	case enc.contentType == contentTypeCBOR:
+		return cbor.Marshal(data)
+	case enc.pretty:
+		return json.MarshalIndent(data, "", "    ")
+	}
+
+	return json.Marshal(data)
+}
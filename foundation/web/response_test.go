@@ -0,0 +1,125 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"testing"
+)
+
+// discardWriter is a response writer that only counts the bytes written, so
+// the benchmarks measure the encoding and not the buffering.
+type discardWriter struct {
+	header http.Header
+	n      int
+}
+
+func (dw *discardWriter) Header() http.Header {
+	return dw.header
+}
+
+func (dw *discardWriter) Write(p []byte) (int, error) {
+	dw.n += len(p)
+	return len(p), nil
+}
+
+func (dw *discardWriter) WriteHeader(statusCode int) {}
+
+// rpcResult represents a result in a batch of JSON-RPC responses, similar
+// to what the node returns for a block with its transactions.
+type rpcResult struct {
+	JSONRPC string   `json:"jsonrpc"`
+	ID      int      `json:"id"`
+	Result  rpcBlock `json:"result"`
+}
+
+type rpcBlock struct {
+	Number       uint64   `json:"number"`
+	Hash         string   `json:"hash"`
+	ParentHash   string   `json:"parentHash"`
+	Miner        string   `json:"miner"`
+	Difficulty   *big.Int `json:"difficulty"`
+	Timestamp    uint64   `json:"timestamp"`
+	Transactions []rpcTx  `json:"transactions"`
+}
+
+type rpcTx struct {
+	Hash  string   `json:"hash"`
+	From  string   `json:"from"`
+	To    string   `json:"to"`
+	Value *big.Int `json:"value"`
+	Nonce uint64   `json:"nonce"`
+	Data  []byte   `json:"data,omitempty"`
+}
+
+// rpcBatch constructs a batch of 40 responses with 10 transactions each.
+func rpcBatch() []rpcResult {
+	batch := make([]rpcResult, 40)
+	for i := range batch {
+		txs := make([]rpcTx, 10)
+		for j := range txs {
+			txs[j] = rpcTx{
+				Hash:  fmt.Sprintf("0x%064x", i*100+j),
+				From:  fmt.Sprintf("0x%040x", j),
+				To:    fmt.Sprintf("0x%040x", j+1),
+				Value: big.NewInt(int64(i*1_000_000 + j)),
+				Nonce: uint64(j),
+				Data:  []byte("transfer"),
+			}
+		}
+
+		batch[i] = rpcResult{
+			JSONRPC: "2.0",
+			ID:      i,
+			Result: rpcBlock{
+				Number:       uint64(i),
+				Hash:         fmt.Sprintf("0x%064x", i+1),
+				ParentHash:   fmt.Sprintf("0x%064x", i),
+				Miner:        fmt.Sprintf("0x%040x", i%3),
+				Difficulty:   big.NewInt(6),
+				Timestamp:    1_700_000_000 + uint64(i),
+				Transactions: txs,
+			},
+		}
+	}
+
+	return batch
+}
+
+func BenchmarkRespond(b *testing.B) {
+	batch := rpcBatch()
+
+	encodings := []struct {
+		name string
+		enc  encoding
+	}{
+		{name: "json", enc: encoding{contentType: contentTypeJSON}},
+		{name: "json-gzip", enc: encoding{contentType: contentTypeJSON, gzip: true}},
+		{name: "pretty", enc: encoding{contentType: contentTypeJSON, pretty: true}},
+		{name: "pretty-gzip", enc: encoding{contentType: contentTypeJSON, pretty: true, gzip: true}},
+		{name: "cbor", enc: encoding{contentType: contentTypeCBOR}},
+		{name: "cbor-gzip", enc: encoding{contentType: contentTypeCBOR, gzip: true}},
+	}
+
+	for _, e := range encodings {
+		b.Run(e.name, func(b *testing.B) {
+			ctx := context.WithValue(context.Background(), key, &Values{})
+			ctx = context.WithValue(ctx, encodingKey, e.enc)
+
+			var dw discardWriter
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				dw = discardWriter{header: make(http.Header)}
+				if err := Respond(ctx, &dw, batch, http.StatusOK); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			b.ReportMetric(float64(dw.n), "bytes/resp")
+		})
+	}
+}
@@ -8,8 +8,9 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/ardanlabs/blockchain/foundation/tracer"
 	"github.com/dimfeld/httptreemux/v5"
-	"github.com/google/uuid"
+	"go.uber.org/zap"
 )
 
 // A Handler is a type that handles a http request within our own little mini
@@ -22,26 +23,43 @@ type Handler func(ctx context.Context, w http.ResponseWriter, r *http.Request) e
 type App struct {
 	*httptreemux.ContextMux
 	shutdown chan os.Signal
+	log      *zap.SugaredLogger
+	tracer   *tracer.Tracer
 	mw       []Middleware
+	routes   []*Route
+
+	maxBodySize int64
+	validator   Validator
 }
 
 // NewApp creates an App value that handle a set of routes for the application.
-func NewApp(shutdown chan os.Signal, mw ...Middleware) *App {
-
-	// Create an OpenTelemetry HTTP Handler which wraps our router. This will start
-	// the initial span and annotate it with information about the request/response.
-	//
-	// This is configured to use the W3C TraceContext standard to set the remote
-	// parent if a client request includes the appropriate headers.
-	// https://w3c.github.io/trace-context/
-
+// The tracer starts a span for each request, using the W3C TraceContext
+// standard to set the remote parent if a client request includes the
+// appropriate headers.
+func NewApp(shutdown chan os.Signal, log *zap.SugaredLogger, tracer *tracer.Tracer, mw ...Middleware) *App {
 	return &App{
 		ContextMux: httptreemux.NewContextMux(),
 		shutdown:   shutdown,
+		log:        log,
+		tracer:     tracer,
 		mw:         mw,
 	}
 }
 
+// SetMaxBodySize limits the size of request bodies. Reading more than the
+// limit fails, which Decode reports with a 413 status. Zero means no limit.
+// It must be called before the app starts serving requests.
+func (a *App) SetMaxBodySize(n int64) {
+	a.maxBodySize = n
+}
+
+// SetValidator sets the function Decode uses to check struct values after
+// they are decoded. It must be called before the app starts serving
+// requests.
+func (a *App) SetValidator(validator Validator) {
+	a.validator = validator
+}
+
 // SignalShutdown is used to gracefully shut down the app when an integrity
 // issue is identified.
 func (a *App) SignalShutdown() {
@@ -49,8 +67,9 @@ func (a *App) SignalShutdown() {
 }
 
 // Handle sets a handler function for a given HTTP method and path pair
-// to the application server mux.
-func (a *App) Handle(method string, group string, path string, handler Handler, mw ...Middleware) {
+// to the application server mux. The returned route can be used to
+// document the route.
+func (a *App) Handle(method string, group string, path string, handler Handler, mw ...Middleware) *Route {
 
 	// First wrap handler specific middleware around this handler.
 	handler = wrapMiddleware(mw, handler)
@@ -58,6 +77,11 @@ func (a *App) Handle(method string, group string, path string, handler Handler,
 	// Add the application's general middleware to the handler chain.
 	handler = wrapMiddleware(a.mw, handler)
 
+	finalPath := path
+	if group != "" {
+		finalPath = "/" + group + path
+	}
+
 	// The function to execute for each request.
 	h := func(w http.ResponseWriter, r *http.Request) {
 
@@ -65,24 +89,120 @@ func (a *App) Handle(method string, group string, path string, handler Handler,
 		// use it as a separate parameter.
 		ctx := r.Context()
 
+		// Continue the caller's trace if the request carries a trace
+		// context, otherwise a new trace is started.
+		if sc, ok := tracer.Extract(r.Header); ok {
+			ctx = tracer.ContextWithRemote(ctx, sc)
+		}
+
+		ctx, span := a.tracer.Start(ctx, method+" "+finalPath)
+		span.SetAttribute("http.method", r.Method)
+		span.SetAttribute("http.target", r.URL.RequestURI())
+
 		// Set the context with the required values to
 		// process the request.
 		v := Values{
-			TraceID: uuid.New().String(),
+			TraceID: span.TraceID(),
+			Route:   finalPath,
 			Now:     time.Now().UTC(),
 		}
 		ctx = context.WithValue(ctx, key, &v)
 
+		// Respond only has access to the context, so the encoding the
+		// client accepts is decided here.
+		ctx = context.WithValue(ctx, encodingKey, negotiate(r))
+
+		// Decode only has access to the request, so the validator is
+		// stored in the request's context.
+		if a.validator != nil {
+			ctx = context.WithValue(ctx, validatorKey, a.validator)
+			r = r.WithContext(ctx)
+		}
+
+		if a.maxBodySize > 0 && r.Body != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, a.maxBodySize)
+		}
+
 		// Call the wrapped handler functions.
 		if err := handler(ctx, w, r); err != nil {
-			a.SignalShutdown()
-			return
+			a.handleError(ctx, w, &v, err)
 		}
-	}
 
-	finalPath := path
-	if group != "" {
-		finalPath = "/" + group + path
+		span.SetAttribute("http.status_code", v.StatusCode)
+		span.End()
 	}
+
 	a.ContextMux.Handle(method, finalPath, h)
+
+	route := Route{
+		Method: method,
+		Path:   finalPath,
+	}
+	a.routes = append(a.routes, &route)
+
+	return &route
+}
+
+// handleError deals with an error that made it out of the middleware chain.
+// The error is logged and, if nothing was sent to the client yet, answered
+// with a 500. Only a shutdown error signals a shutdown.
+func (a *App) handleError(ctx context.Context, w http.ResponseWriter, v *Values, err error) {
+	if IsShutdown(err) {
+		a.log.Errorw("shutdown", "traceid", v.TraceID, "status", "integrity issue, signaling shutdown", "ERROR", err)
+		defer a.SignalShutdown()
+	} else {
+		a.log.Errorw("unhandled error", "traceid", v.TraceID, "ERROR", err)
+	}
+
+	// A status code means a response was already written.
+	if v.StatusCode != 0 {
+		return
+	}
+
+	resp := struct {
+		Error string `json:"error"`
+	}{
+		Error: http.StatusText(http.StatusInternalServerError),
+	}
+
+	if err := Respond(ctx, w, resp, http.StatusInternalServerError); err != nil {
+		a.log.Errorw("unhandled error", "traceid", v.TraceID, "status", "unable to respond", "ERROR", err)
+	}
+}
+
+// Routes returns a copy of the routes registered with the app.
+func (a *App) Routes() []Route {
+	routes := make([]Route, len(a.routes))
+	for i, route := range a.routes {
+		routes[i] = *route
+	}
+	return routes
+}
+
+// =============================================================================
+
+// Route represents a route registered with the app.
+type Route struct {
+	Method string
+	Path   string
+	Doc    *Doc
+}
+
+// Doc describes a route for the generation of API documentation. Request and
+// Response hold a value of the type that is decoded from the request body and
+// sent back in the response.
+type Doc struct {
+	Summary     string
+	Description string
+	Query       map[string]string
+	Request     any
+	Response    any
+	ContentType string
+	Status      int
+}
+
+// Document attaches the documentation to the route.
+func (r *Route) Document(doc Doc) *Route {
+	r.Doc = &doc
+	return r
 }
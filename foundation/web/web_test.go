@@ -0,0 +1,107 @@
+package web_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/ardanlabs/blockchain/foundation/tracer"
+	"github.com/ardanlabs/blockchain/foundation/web"
+	"go.uber.org/zap"
+)
+
+// Success and failure markers.
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+func TestHandleError(t *testing.T) {
+	type table struct {
+		name       string
+		handler    web.Handler
+		statusCode int
+		body       string
+		signal     bool
+	}
+
+	tt := []table{
+		{
+			name: "plain error",
+			handler: func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+				return errors.New("plain error")
+			},
+			statusCode: http.StatusInternalServerError,
+			body:       `{"error":"Internal Server Error"}`,
+			signal:     false,
+		},
+		{
+			name: "shutdown error",
+			handler: func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+				return web.NewShutdownError("integrity issue")
+			},
+			statusCode: http.StatusInternalServerError,
+			body:       `{"error":"Internal Server Error"}`,
+			signal:     true,
+		},
+		{
+			name: "error after a response",
+			handler: func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+				if err := web.Respond(ctx, w, struct{ Status string }{Status: "OK"}, http.StatusOK); err != nil {
+					return err
+				}
+				return errors.New("error after responding")
+			},
+			statusCode: http.StatusOK,
+			body:       `{"Status":"OK"}`,
+			signal:     false,
+		},
+	}
+
+	t.Log("Given the need to handle errors returned by handlers.")
+	{
+		for testID, tst := range tt {
+			f := func(t *testing.T) {
+				t.Logf("\tTest %d:\tWhen handling a %s.", testID, tst.name)
+				{
+					// The channel is buffered so the app can signal without a
+					// receiver.
+					shutdown := make(chan os.Signal, 1)
+
+					app := web.NewApp(shutdown, zap.NewNop().Sugar(), tracer.New("TEST", nil, nil))
+					app.Handle(http.MethodGet, "", "/test", tst.handler)
+
+					w := httptest.NewRecorder()
+					app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+					if w.Code != tst.statusCode {
+						t.Fatalf("\t%s\tTest %d:\tShould get a %d status code : got %d.", failed, testID, tst.statusCode, w.Code)
+					}
+					t.Logf("\t%s\tTest %d:\tShould get a %d status code.", success, testID, tst.statusCode)
+
+					if w.Body.String() != tst.body {
+						t.Fatalf("\t%s\tTest %d:\tShould get the body %s : got %s.", failed, testID, tst.body, w.Body.String())
+					}
+					t.Logf("\t%s\tTest %d:\tShould get the body %s.", success, testID, tst.body)
+
+					var signaled bool
+					select {
+					case <-shutdown:
+						signaled = true
+					default:
+					}
+
+					if signaled != tst.signal {
+						t.Fatalf("\t%s\tTest %d:\tShould signal shutdown %t : got %t.", failed, testID, tst.signal, signaled)
+					}
+					t.Logf("\t%s\tTest %d:\tShould signal shutdown %t.", success, testID, tst.signal)
+				}
+			}
+
+			t.Run(tst.name, f)
+		}
+	}
+}